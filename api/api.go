@@ -41,8 +41,14 @@ import (
 	"github.com/muesli/beehive/api/resources/actions"
 	"github.com/muesli/beehive/api/resources/bees"
 	"github.com/muesli/beehive/api/resources/chains"
+	"github.com/muesli/beehive/api/resources/deadletters"
+	"github.com/muesli/beehive/api/resources/events"
+	"github.com/muesli/beehive/api/resources/executions"
 	"github.com/muesli/beehive/api/resources/hives"
 	"github.com/muesli/beehive/api/resources/logs"
+	"github.com/muesli/beehive/api/resources/scheduledactions"
+	"github.com/muesli/beehive/api/resources/tokens"
+	"github.com/muesli/beehive/api/resources/workqueues"
 	"github.com/muesli/beehive/app"
 )
 
@@ -168,6 +174,10 @@ func Run() {
 	// to see what happens in the package, uncomment the following
 	// restful.TraceLogger(log.New(os.Stdout, "[restful] ", log.LstdFlags|log.Lshortfile))
 
+	if TLSEnabled() {
+		secureCanonicalURL()
+	}
+
 	// Setup web-service
 	smolderConfig := smolder.APIConfig{
 		BaseURL:    canonicalURL,
@@ -180,6 +190,10 @@ func Run() {
 	wsContainer := smolder.NewSmolderContainer(smolderConfig, nil, nil)
 	wsContainer.Router(restful.CurlyRouter{})
 	ws := new(restful.WebService)
+	ws.Route(ws.GET("/v1/stream").To(streamHandler(context)))
+	if metricsEnabled {
+		ws.Route(ws.GET("/metrics").To(metricsHandler(context)))
+	}
 	ws.Route(ws.GET("/images/{subpath:*}").To(assetHandler))
 	ws.Route(ws.GET("/oauth2/{subpath:*}").To(oauth2Handler))
 	ws.Route(ws.GET("/{subpath:*}").To(assetHandler))
@@ -194,13 +208,37 @@ func Run() {
 		&hives.HiveResource{},
 		&bees.BeeResource{},
 		&chains.ChainResource{},
+		&chains.ChainSimulateResource{},
 		&actions.ActionResource{},
+		&actions.ActionRunResource{},
+		&actions.AdHocActionRunResource{},
 		&logs.LogResource{},
+		&deadletters.DeadLetterResource{},
+		&deadletters.DeadLetterRetryResource{},
+		&scheduledactions.ScheduledActionResource{},
+		&scheduledactions.ScheduledActionCancelResource{},
+		&executions.ExecutionResource{},
+		&workqueues.WorkQueueResource{},
+		&events.EventResource{},
+		&tokens.TokenResource{},
+		&tokens.LoginResource{},
 	)
 
 	server := &http.Server{Addr: bind, Handler: wsContainer}
+	if !TLSEnabled() {
+		go func() {
+			log.Fatal(server.ListenAndServe())
+		}()
+		return
+	}
+
+	config, cert, key, err := tlsConfig()
+	if err != nil {
+		log.Fatalf("Error setting up TLS: %v", err)
+	}
+	server.TLSConfig = config
 	go func() {
-		log.Fatal(server.ListenAndServe())
+		log.Fatal(server.ListenAndServeTLS(cert, key))
 	}()
 }
 
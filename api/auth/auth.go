@@ -0,0 +1,189 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package auth manages the access tokens of Beehive's RESTful api
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/muesli/beehive/bees"
+)
+
+// Scopes a token can be granted. Each scope includes the permissions of the
+// scopes before it.
+const (
+	ScopeReadOnly    = "read-only"
+	ScopeChainEditor = "chain-editor"
+	ScopeAdmin       = "admin"
+)
+
+var scopeLevels = map[string]int{
+	ScopeReadOnly:    1,
+	ScopeChainEditor: 2,
+	ScopeAdmin:       3,
+}
+
+// Token is an API access token. Only a hash of the secret is ever stored.
+type Token struct {
+	ID      string
+	Name    string
+	Hash    string
+	Scope   string
+	Created time.Time
+}
+
+var (
+	tokens     []Token
+	tokenMutex sync.RWMutex
+
+	// Anonymous is the identity used for all requests while no tokens are
+	// configured. Only requests from localhost may create the first token
+	Anonymous = &Token{Name: "anonymous", Scope: ScopeAdmin}
+)
+
+// Scopes returns all known scopes, from the least to the most privileged.
+func Scopes() []string {
+	return []string{ScopeReadOnly, ScopeChainEditor, ScopeAdmin}
+}
+
+// ValidScope returns true if s is a known scope.
+func ValidScope(s string) bool {
+	_, ok := scopeLevels[s]
+	return ok
+}
+
+// Allows returns true if the token has been granted the given scope.
+func (t *Token) Allows(scope string) bool {
+	return scopeLevels[t.Scope] >= scopeLevels[scope] && scopeLevels[scope] > 0
+}
+
+// HashToken returns the hash of a token's secret, as stored in the config.
+func HashToken(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// Enabled returns true if authentication is enabled, which is the case as
+// soon as a single token has been configured.
+func Enabled() bool {
+	tokenMutex.RLock()
+	defer tokenMutex.RUnlock()
+
+	return len(tokens) > 0
+}
+
+// GetTokens returns all configured tokens
+func GetTokens() []Token {
+	tokenMutex.RLock()
+	defer tokenMutex.RUnlock()
+
+	return append([]Token{}, tokens...)
+}
+
+// GetToken returns the token with a specific ID
+func GetToken(id string) *Token {
+	tokenMutex.RLock()
+	defer tokenMutex.RUnlock()
+
+	for _, t := range tokens {
+		if t.ID == id {
+			return &t
+		}
+	}
+
+	return nil
+}
+
+// SetTokens sets the currently configured tokens
+func SetTokens(ts []Token) {
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+
+	tokens = append([]Token{}, ts...)
+}
+
+// CreateToken generates a new token with the given scope. The secret is
+// returned once and can't be recovered later on.
+func CreateToken(name, scope string) (Token, string, error) {
+	if !ValidScope(scope) {
+		return Token{}, "", errors.New("Unknown scope " + scope)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Token{}, "", err
+	}
+	secret := hex.EncodeToString(b)
+
+	t := Token{
+		ID:      bees.UUID(),
+		Name:    name,
+		Hash:    HashToken(secret),
+		Scope:   scope,
+		Created: time.Now(),
+	}
+
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+	tokens = append(tokens, t)
+
+	return t, secret, nil
+}
+
+// DeleteToken revokes a token. Returns false if no token with this ID exists.
+func DeleteToken(id string) bool {
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+
+	for i, t := range tokens {
+		if t.ID == id {
+			tokens = append(tokens[:i], tokens[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticate returns the token matching a secret, or nil if there is none.
+func Authenticate(secret string) *Token {
+	if len(secret) == 0 {
+		return nil
+	}
+	hash := []byte(HashToken(secret))
+
+	tokenMutex.RLock()
+	defer tokenMutex.RUnlock()
+
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(t.Hash)) == 1 {
+			return &t
+		}
+	}
+
+	return nil
+}
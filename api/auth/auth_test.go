@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	SetTokens(nil)
+	if Enabled() {
+		t.Error("Authentication should be disabled without tokens")
+	}
+
+	token, secret, err := CreateToken("editor", ScopeChainEditor)
+	if err != nil {
+		t.Fatalf("Error creating token: %v", err)
+	}
+	if token.Hash == secret {
+		t.Error("Token secret must not be stored in plain text")
+	}
+	if !Enabled() {
+		t.Error("Authentication should be enabled once a token exists")
+	}
+
+	if Authenticate("invalid") != nil {
+		t.Error("Invalid secret got authenticated")
+	}
+	a := Authenticate(secret)
+	if a == nil || a.ID != token.ID {
+		t.Fatal("Valid secret did not get authenticated")
+	}
+
+	if !a.Allows(ScopeReadOnly) || !a.Allows(ScopeChainEditor) || a.Allows(ScopeAdmin) {
+		t.Errorf("Unexpected permissions for scope %s", a.Scope)
+	}
+
+	if _, _, err := CreateToken("invalid", "superuser"); err == nil {
+		t.Error("Expected an error for an unknown scope")
+	}
+
+	if !DeleteToken(token.ID) || Authenticate(secret) != nil {
+		t.Error("Revoked token still authenticates")
+	}
+}
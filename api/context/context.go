@@ -21,12 +21,20 @@
 package context
 
 import (
+	"errors"
+	"net"
+	"net/http"
 	"strings"
 
 	restful "github.com/emicklei/go-restful"
 	"github.com/muesli/smolder"
+
+	"github.com/muesli/beehive/api/auth"
 )
 
+// TokenCookie is the name of the cookie the admin UI keeps its token in
+const TokenCookie = "beehive_token"
+
 // APIContext is polly's central context
 type APIContext struct {
 	Config smolder.APIConfig
@@ -40,10 +48,37 @@ func (context *APIContext) NewAPIContext() smolder.APIContext {
 	return ctx
 }
 
-// Authentication parses the request for an access-/authtoken and returns the matching user
+// Authentication parses the request for an access-/authtoken and returns the
+// matching token, as long as it has been granted the scope this request
+// requires. While no tokens are configured, all requests are allowed, except
+// for creating the first token from a remote host.
 func (context *APIContext) Authentication(request *restful.Request) (interface{}, error) {
-	//FIXME: implement this properly
+	if !auth.Enabled() {
+		path := resourcePath(request)
+		if strings.HasPrefix(path, "tokens") && request.Request.Method == http.MethodPost && !IsLoopback(request) {
+			return nil, errors.New("The first token can only be created from localhost")
+		}
+		return auth.Anonymous, nil
+	}
+
+	t := AccessToken(request)
+	token := auth.Authenticate(t)
+	if token == nil {
+		return nil, errors.New("Invalid accesstoken")
+	}
+
+	scope := RequiredScope(request)
+	if !token.Allows(scope) {
+		return nil, errors.New("Token lacks the " + scope + " scope")
+	}
+
+	return token, nil
+}
 
+// AccessToken returns the token a request has been made with. It's looked up
+// in the accesstoken query parameter, the Authorization header and the admin
+// UI's cookie, in that order.
+func AccessToken(request *restful.Request) string {
 	t := request.QueryParameter("accesstoken")
 	if len(t) == 0 {
 		t = request.HeaderParameter("authorization")
@@ -51,8 +86,55 @@ func (context *APIContext) Authentication(request *restful.Request) (interface{}
 			t = strings.TrimSpace(strings.Split(t, " ")[1])
 		}
 	}
+	if len(t) == 0 {
+		if c, err := request.Request.Cookie(TokenCookie); err == nil {
+			t = c.Value
+		}
+	}
+
+	return t
+}
+
+// IsLoopback returns true if a request has been made from localhost.
+func IsLoopback(request *restful.Request) bool {
+	host, _, err := net.SplitHostPort(request.Request.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// RequiredScope returns the scope a request requires. Reading is allowed for
+// every token, editing chains, actions and events requires the chain-editor
+// scope, everything else (like managing bees & tokens, or running actions
+// directly, which might execute commands) is reserved for admins.
+func RequiredScope(request *restful.Request) string {
+	path := strings.TrimSuffix(resourcePath(request), "/")
+	resource := strings.Split(path, "/")[0]
+
+	if resource == "tokens" || (resource == "actions" && strings.HasSuffix(path, "/run")) {
+		return auth.ScopeAdmin
+	}
+
+	switch request.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return auth.ScopeReadOnly
+	}
+
+	switch resource {
+	case "chains", "actions", "events", "deadletters", "scheduledactions":
+		return auth.ScopeChainEditor
+	}
+
+	return auth.ScopeAdmin
+}
 
-	return nil, nil // context.GetUserByAccessToken(t)
+// resourcePath returns the path of a request, relative to the API's root.
+func resourcePath(request *restful.Request) string {
+	p := strings.TrimPrefix(request.Request.URL.Path, "/")
+	return strings.TrimPrefix(p, "v1/")
 }
 
 // LogSummary logs out the current context stats
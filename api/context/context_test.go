@@ -0,0 +1,78 @@
+package context
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	restful "github.com/emicklei/go-restful"
+
+	"github.com/muesli/beehive/api/auth"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		scope  string
+	}{
+		{"GET", "/v1/chains", auth.ScopeReadOnly},
+		{"POST", "/v1/chains", auth.ScopeChainEditor},
+		{"POST", "/v1/actions", auth.ScopeChainEditor},
+		{"POST", "/v1/actions/42/run", auth.ScopeAdmin},
+		{"POST", "/v1/actions/run", auth.ScopeAdmin},
+		{"GET", "/v1/tokens", auth.ScopeAdmin},
+		{"POST", "/v1/bees", auth.ScopeAdmin},
+	}
+
+	for _, test := range tests {
+		r := restful.NewRequest(httptest.NewRequest(test.method, test.path, nil))
+		if s := RequiredScope(r); s != test.scope {
+			t.Errorf("Expected %s %s to require %s, got %s", test.method, test.path, test.scope, s)
+		}
+	}
+}
+
+func TestBootstrapToken(t *testing.T) {
+	auth.SetTokens(nil)
+	defer auth.SetTokens(nil)
+
+	context := &APIContext{}
+	r := httptest.NewRequest("POST", "/v1/tokens", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	if _, err := context.Authentication(restful.NewRequest(r)); err == nil {
+		t.Error("Expected remote hosts not to be able to create the first token")
+	}
+
+	r.RemoteAddr = "127.0.0.1:1234"
+	if _, err := context.Authentication(restful.NewRequest(r)); err != nil {
+		t.Errorf("Expected localhost to be able to create the first token: %v", err)
+	}
+
+	r = httptest.NewRequest("GET", "/v1/chains", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	if _, err := context.Authentication(restful.NewRequest(r)); err != nil {
+		t.Errorf("Expected requests to be allowed without tokens: %v", err)
+	}
+}
+
+func TestChainEditorCantRunActions(t *testing.T) {
+	auth.SetTokens(nil)
+	defer auth.SetTokens(nil)
+	_, secret, err := auth.CreateToken("editor", auth.ScopeChainEditor)
+	if err != nil {
+		t.Fatalf("Error creating token: %v", err)
+	}
+
+	context := &APIContext{}
+	for _, path := range []string{"/v1/actions/run", "/v1/actions/42/run"} {
+		r := httptest.NewRequest("POST", path+"?accesstoken="+secret, nil)
+		if _, err := context.Authentication(restful.NewRequest(r)); err == nil {
+			t.Errorf("Expected a chain-editor token not to be allowed to run actions via %s", path)
+		}
+	}
+
+	r := httptest.NewRequest("POST", "/v1/chains?accesstoken="+secret, nil)
+	if _, err := context.Authentication(restful.NewRequest(r)); err != nil {
+		t.Errorf("Expected a chain-editor token to be allowed to edit chains: %v", err)
+	}
+}
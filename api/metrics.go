@@ -0,0 +1,64 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/smolder"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	bee "github.com/muesli/beehive/bees"
+
+	"github.com/muesli/beehive/app"
+)
+
+var (
+	metricsEnabled bool
+)
+
+// metricsHandler returns the handler for /metrics, which exports the engine's
+// metrics for Prometheus.
+func metricsHandler(contextFactory smolder.APIContextFactory) restful.RouteFunction {
+	h := promhttp.HandlerFor(bee.Metrics(), promhttp.HandlerOpts{})
+
+	return func(req *restful.Request, resp *restful.Response) {
+		ctx := contextFactory.NewAPIContext()
+		if auth, err := ctx.Authentication(req); err != nil || auth == nil {
+			http.Error(resp.ResponseWriter, "Invalid accesstoken", http.StatusUnauthorized)
+			return
+		}
+
+		h.ServeHTTP(resp.ResponseWriter, req.Request)
+	}
+}
+
+func init() {
+	app.AddFlags([]app.CliFlag{
+		{
+			V:     &metricsEnabled,
+			Name:  "metrics",
+			Value: false,
+			Desc:  "Export Prometheus metrics on /metrics",
+		},
+	})
+}
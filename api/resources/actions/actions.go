@@ -21,7 +21,10 @@
 package actions
 
 import (
+	"errors"
+
 	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/bees"
 	"github.com/muesli/smolder"
 )
 
@@ -61,7 +64,14 @@ func (r *ActionResource) Returns() interface{} {
 
 // Validate checks an incoming request for data errors
 func (r *ActionResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
-	//	ps := data.(*ActionPostStruct)
+	ps := data.(*ActionPostStruct)
+	if !bees.ValidFailurePolicy(ps.Action.OnFailure) {
+		return errors.New("Invalid onfailure policy: " + ps.Action.OnFailure)
+	}
+	if err := ps.Action.Schedule.Validate(); err != nil {
+		return err
+	}
+
 	// FIXME
 	return nil
 }
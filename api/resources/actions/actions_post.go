@@ -29,15 +29,17 @@ import (
 // ActionPostStruct holds all values of an incoming POST request
 type ActionPostStruct struct {
 	Action struct {
-		Bee     string            `json:"bee"`
-		Name    string            `json:"name"`
-		Options bees.Placeholders `json:"options"`
+		Bee       string               `json:"bee"`
+		Name      string               `json:"name"`
+		Options   bees.Placeholders    `json:"options"`
+		OnFailure string               `json:"onfailure,omitempty"`
+		Schedule  *bees.ActionSchedule `json:"schedule,omitempty"`
 	} `json:"action"`
 }
 
 // PostAuthRequired returns true because all requests need authentication
 func (r *ActionResource) PostAuthRequired() bool {
-	return false
+	return true
 }
 
 // PostDoc returns the description of this API endpoint
@@ -57,13 +59,14 @@ func (r *ActionResource) Post(context smolder.APIContext, data interface{}, requ
 
 	pps := data.(*ActionPostStruct)
 	action := bees.Action{
-		ID:      bees.UUID(),
-		Bee:     pps.Action.Bee,
-		Name:    pps.Action.Name,
-		Options: pps.Action.Options,
+		ID:        bees.UUID(),
+		Bee:       pps.Action.Bee,
+		Name:      pps.Action.Name,
+		Options:   pps.Action.Options,
+		OnFailure: pps.Action.OnFailure,
+		Schedule:  pps.Action.Schedule,
 	}
-	actions := append(bees.GetActions(), action)
-	bees.SetActions(actions)
+	bees.AddAction(action)
 
 	resp.AddAction(&action)
 	resp.Send(response)
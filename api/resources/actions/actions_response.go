@@ -35,10 +35,12 @@ type ActionResponse struct {
 }
 
 type actionInfoResponse struct {
-	ID      string            `json:"id"`
-	Bee     string            `json:"bee"`
-	Name    string            `json:"name"`
-	Options bees.Placeholders `json:"options"`
+	ID        string               `json:"id"`
+	Bee       string               `json:"bee"`
+	Name      string               `json:"name"`
+	Options   bees.Placeholders    `json:"options"`
+	OnFailure string               `json:"onfailure,omitempty"`
+	Schedule  *bees.ActionSchedule `json:"schedule,omitempty"`
 }
 
 // Init a new response
@@ -70,10 +72,12 @@ func (r *ActionResponse) EmptyResponse() interface{} {
 func prepareActionResponse(context smolder.APIContext, action *bees.Action) actionInfoResponse {
 	//	ctx := context.(*context.APIContext)
 	resp := actionInfoResponse{
-		ID:      (*action).ID,
-		Bee:     (*action).Bee,
-		Name:    (*action).Name,
-		Options: (*action).Options,
+		ID:        (*action).ID,
+		Bee:       (*action).Bee,
+		Name:      (*action).Name,
+		Options:   (*action).Options,
+		OnFailure: (*action).OnFailure,
+		Schedule:  (*action).Schedule,
 	}
 
 	return resp
@@ -0,0 +1,201 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package actions
+
+import (
+	"encoding/json"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/bees"
+	"github.com/muesli/smolder"
+)
+
+// ActionRunResource is the resource responsible for /actions/{id}/run
+type ActionRunResource struct {
+	smolder.Resource
+}
+
+// AdHocActionRunResource is the resource responsible for /actions/run
+type AdHocActionRunResource struct {
+	smolder.Resource
+}
+
+// ActionRunStruct holds all values of an incoming run request. Data is made
+// available to the action's option templates. Action is only used for ad-hoc
+// actions, which don't need to be configured beforehand.
+type ActionRunStruct struct {
+	Data   map[string]interface{} `json:"data"`
+	Action struct {
+		Bee     string            `json:"bee"`
+		Name    string            `json:"name"`
+		Options bees.Placeholders `json:"options"`
+	} `json:"action"`
+}
+
+// ActionRunResponse is the response to a run request
+type ActionRunResponse struct {
+	smolder.Response
+
+	Placeholders bees.Placeholders `json:"placeholders"`
+	Error        string            `json:"error,omitempty"`
+}
+
+var (
+	_ smolder.PostSupported = &ActionRunResource{}
+	_ smolder.PostSupported = &AdHocActionRunResource{}
+)
+
+// Register this resource with the container to setup all the routes
+func (r *ActionRunResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "ActionRunResource"
+	r.TypeName = "action"
+	r.Endpoint = "actions/{action-id}/run"
+	r.Doc = "Run configured actions"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Reads returns the model that will be read by POST, PUT & PATCH operations
+func (r *ActionRunResource) Reads() interface{} {
+	return &ActionRunStruct{}
+}
+
+// Returns returns the model that will be returned
+func (r *ActionRunResource) Returns() interface{} {
+	return ActionRunResponse{}
+}
+
+// Validate checks an incoming request for data errors
+func (r *ActionRunResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
+	return nil
+}
+
+// PostAuthRequired returns true because all requests need authentication
+func (r *ActionRunResource) PostAuthRequired() bool {
+	return true
+}
+
+// PostDoc returns the description of this API endpoint
+func (r *ActionRunResource) PostDoc() string {
+	return "run a configured action"
+}
+
+// PostParams returns the parameters supported by this API endpoint
+func (r *ActionRunResource) PostParams() []*restful.Parameter {
+	return nil
+}
+
+// Post processes an incoming POST (create) request
+func (r *ActionRunResource) Post(context smolder.APIContext, data interface{}, request *restful.Request, response *restful.Response) {
+	action := bees.GetAction(request.PathParameter("action-id"))
+	if action == nil {
+		r.NotFound(request, response)
+		return
+	}
+
+	pps := data.(*ActionRunStruct)
+	runAction(context, response, *action, pps.Data)
+}
+
+// Register this resource with the container to setup all the routes
+func (r *AdHocActionRunResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "AdHocActionRunResource"
+	r.TypeName = "action"
+	r.Endpoint = "actions/run"
+	r.Doc = "Run ad-hoc actions"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Reads returns the model that will be read by POST, PUT & PATCH operations
+func (r *AdHocActionRunResource) Reads() interface{} {
+	return &ActionRunStruct{}
+}
+
+// Returns returns the model that will be returned
+func (r *AdHocActionRunResource) Returns() interface{} {
+	return ActionRunResponse{}
+}
+
+// Validate checks an incoming request for data errors
+func (r *AdHocActionRunResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
+	return nil
+}
+
+// PostAuthRequired returns true because all requests need authentication
+func (r *AdHocActionRunResource) PostAuthRequired() bool {
+	return true
+}
+
+// PostDoc returns the description of this API endpoint
+func (r *AdHocActionRunResource) PostDoc() string {
+	return "run an ad-hoc action"
+}
+
+// PostParams returns the parameters supported by this API endpoint
+func (r *AdHocActionRunResource) PostParams() []*restful.Parameter {
+	return nil
+}
+
+// Post processes an incoming POST (create) request
+func (r *AdHocActionRunResource) Post(context smolder.APIContext, data interface{}, request *restful.Request, response *restful.Response) {
+	pps := data.(*ActionRunStruct)
+	action := bees.Action{
+		Bee:     pps.Action.Bee,
+		Name:    pps.Action.Name,
+		Options: pps.Action.Options.Normalize(),
+	}
+
+	runAction(context, response, action, pps.Data)
+}
+
+// runAction executes an action and sends out its results
+func runAction(context smolder.APIContext, response *restful.Response, action bees.Action, data map[string]interface{}) {
+	resp := ActionRunResponse{}
+	resp.Parent = &resp
+	resp.Context = context
+
+	m := make(map[string]interface{})
+	for k, v := range data {
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v = i
+			} else if f, err := n.Float64(); err == nil {
+				v = f
+			}
+		}
+		m[k] = v
+	}
+
+	phs, err := bees.RunAction(action, m)
+	resp.Placeholders = append(bees.Placeholders{}, phs...)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	resp.Send(response)
+}
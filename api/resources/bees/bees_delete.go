@@ -28,7 +28,7 @@ import (
 
 // DeleteAuthRequired returns true because all requests need authentication
 func (r *BeeResource) DeleteAuthRequired() bool {
-	return false
+	return true
 }
 
 // DeleteDoc returns the description of this API endpoint
@@ -39,7 +39,7 @@ type BeePostStruct struct {
 
 // PostAuthRequired returns true because all requests need authentication
 func (r *BeeResource) PostAuthRequired() bool {
-	return false
+	return true
 }
 
 // PostDoc returns the description of this API endpoint
@@ -28,7 +28,7 @@ import (
 
 // PutAuthRequired returns true because all requests need authentication
 func (r *BeeResource) PutAuthRequired() bool {
-	return false
+	return true
 }
 
 // PutDoc returns the description of this API endpoint
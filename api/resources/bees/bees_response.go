@@ -50,6 +50,10 @@ type beeInfoResponse struct {
 	LastAction  time.Time        `json:"lastaction"`
 	LastEvent   time.Time        `json:"lastevent"`
 	Active      bool             `json:"active"`
+	State       string           `json:"state"`
+	StateSince  time.Time        `json:"statesince"`
+	LastError   string           `json:"lasterror,omitempty"`
+	Restarts    int              `json:"restarts"`
 	Options     []bees.BeeOption `json:"options"`
 }
 
@@ -104,6 +108,7 @@ func (r *BeeResponse) EmptyResponse() interface{} {
 
 func prepareBeeResponse(context smolder.APIContext, bee *bees.BeeInterface) beeInfoResponse {
 	//	ctx := context.(*context.APIContext)
+	status := (*bee).Status()
 	resp := beeInfoResponse{
 		ID:          (*bee).Name(),
 		Name:        (*bee).Name(),
@@ -112,6 +117,10 @@ func prepareBeeResponse(context smolder.APIContext, bee *bees.BeeInterface) beeI
 		LastAction:  (*bee).LastAction(),
 		LastEvent:   (*bee).LastEvent(),
 		Active:      (*bee).IsRunning(),
+		State:       string(status.State),
+		StateSince:  status.Since,
+		LastError:   status.LastError,
+		Restarts:    status.Restarts,
 		Options:     (*bee).Options(),
 	}
 
@@ -62,7 +62,10 @@ func (r *ChainResource) Returns() interface{} {
 
 // Validate checks an incoming request for data errors
 func (r *ChainResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
-	//	ps := data.(*ChainPostStruct)
+	ps := data.(*ChainPostStruct)
 	// FIXME
-	return nil
+	if err := ps.Chain.Limits.Validate(); err != nil {
+		return err
+	}
+	return ps.Chain.Correlation.Validate()
 }
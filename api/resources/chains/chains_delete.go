@@ -28,7 +28,7 @@ import (
 
 // DeleteAuthRequired returns true because all requests need authentication
 func (r *ChainResource) DeleteAuthRequired() bool {
-	return false
+	return true
 }
 
 // DeleteDoc returns the description of this API endpoint
@@ -47,19 +47,7 @@ func (r *ChainResource) Delete(context smolder.APIContext, request *restful.Requ
 	resp.Init(context)
 
 	id := request.PathParameter("chain-id")
-
-	found := false
-	chains := []bees.Chain{}
-	for _, v := range bees.GetChains() {
-		if v.Name == id {
-			found = true
-		} else {
-			chains = append(chains, v)
-		}
-	}
-
-	if found {
-		bees.SetChains(chains)
+	if bees.DeleteChain(id) {
 		resp.Send(response)
 	} else {
 		r.NotFound(request, response)
@@ -21,27 +21,54 @@
 package chains
 
 import (
-	"errors"
-
 	"github.com/emicklei/go-restful"
 	"github.com/muesli/beehive/bees"
 	"github.com/muesli/smolder"
 )
 
+// ChainStruct holds a chain definition, as sent in POST and simulation
+// requests
+type ChainStruct struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Event       bees.Event             `json:"event"`
+	Triggers    []bees.Trigger         `json:"triggers,omitempty"`
+	Transforms  []bees.ChainTransform  `json:"transforms,omitempty"`
+	Filters     []string               `json:"filters"`
+	Actions     []string               `json:"actions"`
+	Branches    []bees.Branch          `json:"branches,omitempty"`
+	OnError     []string               `json:"onerror,omitempty"`
+	Outputs     []bees.ChainOutput     `json:"outputs,omitempty"`
+	Limits      *bees.ChainLimits      `json:"limits,omitempty"`
+	Correlation *bees.ChainCorrelation `json:"correlation,omitempty"`
+}
+
 // ChainPostStruct holds all values of an incoming POST request
 type ChainPostStruct struct {
-	Chain struct {
-		Name        string     `json:"name"`
-		Description string     `json:"description"`
-		Event       bees.Event `json:"event"`
-		Filters     []string   `json:"filters"`
-		Actions     []string   `json:"actions"`
-	} `json:"chain"`
+	Chain ChainStruct `json:"chain"`
+}
+
+// toChain converts a chain definition to a bees.Chain
+func (s *ChainStruct) toChain() bees.Chain {
+	return bees.Chain{
+		Name:        s.Name,
+		Description: s.Description,
+		Event:       &s.Event,
+		Triggers:    s.Triggers,
+		Actions:     s.Actions,
+		Branches:    s.Branches,
+		OnError:     s.OnError,
+		Outputs:     s.Outputs,
+		Transforms:  s.Transforms,
+		Filters:     s.Filters,
+		Limits:      s.Limits,
+		Correlation: s.Correlation,
+	}
 }
 
 // PostAuthRequired returns true because all requests need authentication
 func (r *ChainResource) PostAuthRequired() bool {
-	return false
+	return true
 }
 
 // PostDoc returns the description of this API endpoint
@@ -60,25 +87,16 @@ func (r *ChainResource) Post(context smolder.APIContext, data interface{}, reque
 	resp.Init(context)
 
 	pps := data.(*ChainPostStruct)
-	dupe := bees.GetChain(pps.Chain.Name)
-	if dupe != nil {
+	chain := pps.Chain.toChain()
+	err := bees.AddChain(chain)
+	if err != nil {
 		smolder.ErrorResponseHandler(request, response, nil, smolder.NewErrorResponse(
 			422, // Go 1.7+: http.StatusUnprocessableEntity,
-			errors.New("A Chain with that name exists already"),
+			err,
 			"ChainResource POST"))
 		return
 	}
 
-	chain := bees.Chain{
-		Name:        pps.Chain.Name,
-		Description: pps.Chain.Description,
-		Event:       &pps.Chain.Event,
-		Actions:     pps.Chain.Actions,
-		Filters:     pps.Chain.Filters,
-	}
-	chains := append(bees.GetChains(), chain)
-	bees.SetChains(chains)
-
 	resp.AddChain(chain)
 	resp.Send(response)
 }
@@ -38,12 +38,20 @@ type ChainResponse struct {
 }
 
 type chainInfoResponse struct {
-	ID          string      `json:"id"`
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	Event       *bees.Event `json:"event"`
-	Filters     []string    `json:"filters,omitempty"`
-	Actions     []string    `json:"actions"`
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Event       *bees.Event            `json:"event"`
+	Triggers    []bees.Trigger         `json:"triggers,omitempty"`
+	Transforms  []bees.ChainTransform  `json:"transforms,omitempty"`
+	Filters     []string               `json:"filters,omitempty"`
+	Actions     []string               `json:"actions"`
+	Branches    []bees.Branch          `json:"branches,omitempty"`
+	OnError     []string               `json:"onerror,omitempty"`
+	Outputs     []bees.ChainOutput     `json:"outputs,omitempty"`
+	Limits      *bees.ChainLimits      `json:"limits,omitempty"`
+	Correlation *bees.ChainCorrelation `json:"correlation,omitempty"`
+	Stats       bees.ChainStats        `json:"stats"`
 }
 
 // Init a new response
@@ -93,8 +101,16 @@ func prepareChainResponse(context smolder.APIContext, chain *bees.Chain) chainIn
 		Name:        (*chain).Name,
 		Description: (*chain).Description,
 		Event:       (*chain).Event,
+		Triggers:    (*chain).Triggers,
 		Actions:     (*chain).Actions,
+		Branches:    (*chain).Branches,
+		OnError:     (*chain).OnError,
+		Outputs:     (*chain).Outputs,
+		Transforms:  (*chain).Transforms,
 		Filters:     (*chain).Filters,
+		Limits:      (*chain).Limits,
+		Correlation: (*chain).Correlation,
+		Stats:       bees.GetChainStats((*chain).Name),
 	}
 
 	return resp
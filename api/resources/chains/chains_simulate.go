@@ -0,0 +1,126 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package chains
+
+import (
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/api/resources/executions"
+	"github.com/muesli/beehive/bees"
+	"github.com/muesli/smolder"
+)
+
+// ChainSimulateResource is the resource responsible for /chains/{id}/simulate
+type ChainSimulateResource struct {
+	smolder.Resource
+}
+
+// ChainSimulateStruct holds all values of an incoming simulation request. If
+// a chain definition is supplied, it gets simulated instead of the stored
+// chain, which allows testing chains before saving them.
+type ChainSimulateStruct struct {
+	Event bees.Event   `json:"event"`
+	Chain *ChainStruct `json:"chain,omitempty"`
+}
+
+// ChainSimulateResponse is the response to a simulation request
+type ChainSimulateResponse struct {
+	smolder.Response
+
+	Simulation executions.ExecutionInfoResponse `json:"simulation"`
+}
+
+var (
+	_ smolder.PostSupported = &ChainSimulateResource{}
+)
+
+// Register this resource with the container to setup all the routes
+func (r *ChainSimulateResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "ChainSimulateResource"
+	r.TypeName = "chain"
+	r.Endpoint = "chains/{chain-id}/simulate"
+	r.Doc = "Test chains against sample events"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Reads returns the model that will be read by POST, PUT & PATCH operations
+func (r *ChainSimulateResource) Reads() interface{} {
+	return &ChainSimulateStruct{}
+}
+
+// Returns returns the model that will be returned
+func (r *ChainSimulateResource) Returns() interface{} {
+	return ChainSimulateResponse{}
+}
+
+// Validate checks an incoming request for data errors
+func (r *ChainSimulateResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
+	return nil
+}
+
+// PostAuthRequired returns true because all requests need authentication
+func (r *ChainSimulateResource) PostAuthRequired() bool {
+	return true
+}
+
+// PostDoc returns the description of this API endpoint
+func (r *ChainSimulateResource) PostDoc() string {
+	return "simulate a chain without executing its actions"
+}
+
+// PostParams returns the parameters supported by this API endpoint
+func (r *ChainSimulateResource) PostParams() []*restful.Parameter {
+	return nil
+}
+
+// Post processes an incoming POST (create) request
+func (r *ChainSimulateResource) Post(context smolder.APIContext, data interface{}, request *restful.Request, response *restful.Response) {
+	resp := ChainSimulateResponse{}
+	resp.Parent = &resp
+	resp.Context = context
+
+	pps := data.(*ChainSimulateStruct)
+
+	var chain bees.Chain
+	if pps.Chain != nil {
+		chain = pps.Chain.toChain()
+		if len(chain.Name) == 0 {
+			chain.Name = request.PathParameter("chain-id")
+		}
+	} else {
+		c := bees.GetChain(request.PathParameter("chain-id"))
+		if c == nil {
+			r.NotFound(request, response)
+			return
+		}
+		chain = *c
+	}
+
+	event := pps.Event
+	event.Options = event.Options.Normalize()
+
+	x := bees.SimulateChain(chain, event)
+	resp.Simulation = executions.PrepareExecutionResponse(context, &x)
+	resp.Send(response)
+}
@@ -0,0 +1,55 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package deadletters
+
+import (
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/smolder"
+)
+
+// DeadLetterResource is the resource responsible for /deadletters
+type DeadLetterResource struct {
+	smolder.Resource
+}
+
+var (
+	_ smolder.GetIDSupported  = &DeadLetterResource{}
+	_ smolder.GetSupported    = &DeadLetterResource{}
+	_ smolder.DeleteSupported = &DeadLetterResource{}
+)
+
+// Register this resource with the container to setup all the routes
+func (r *DeadLetterResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "DeadLetterResource"
+	r.TypeName = "deadletter"
+	r.Endpoint = "deadletters"
+	r.Doc = "Manage permanently failed actions"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Returns returns the model that will be returned
+func (r *DeadLetterResource) Returns() interface{} {
+	return DeadLetterResponse{}
+}
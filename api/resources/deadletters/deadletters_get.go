@@ -0,0 +1,88 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package deadletters
+
+import (
+	"github.com/muesli/beehive/bees"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/smolder"
+)
+
+// GetAuthRequired returns true because all requests need authentication
+func (r *DeadLetterResource) GetAuthRequired() bool {
+	return true
+}
+
+// GetByIDsAuthRequired returns true because all requests need authentication
+func (r *DeadLetterResource) GetByIDsAuthRequired() bool {
+	return true
+}
+
+// GetDoc returns the description of this API endpoint
+func (r *DeadLetterResource) GetDoc() string {
+	return "retrieve dead letters"
+}
+
+// GetParams returns the parameters supported by this API endpoint
+func (r *DeadLetterResource) GetParams() []*restful.Parameter {
+	params := []*restful.Parameter{}
+	params = append(params, restful.QueryParameter("bee", "id of a bee").DataType("string"))
+
+	return params
+}
+
+// GetByIDs sends out all items matching a set of IDs
+func (r *DeadLetterResource) GetByIDs(ctx smolder.APIContext, request *restful.Request, response *restful.Response, ids []string) {
+	resp := DeadLetterResponse{}
+	resp.Init(ctx)
+
+	for _, id := range ids {
+		dl := bees.GetDeadLetter(id)
+		if dl == nil {
+			r.NotFound(request, response)
+			return
+		}
+
+		resp.AddDeadLetter(dl)
+	}
+
+	resp.Send(response)
+}
+
+// Get sends out items matching the query parameters
+func (r *DeadLetterResource) Get(ctx smolder.APIContext, request *restful.Request, response *restful.Response, params map[string][]string) {
+	bee := request.QueryParameter("bee")
+
+	resp := DeadLetterResponse{}
+	resp.Init(ctx)
+
+	for _, dl := range bees.GetDeadLetters() {
+		if len(bee) > 0 && dl.Action.Bee != bee {
+			continue
+		}
+
+		dl := dl
+		resp.AddDeadLetter(&dl)
+	}
+
+	resp.Send(response)
+}
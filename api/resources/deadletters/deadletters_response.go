@@ -0,0 +1,89 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package deadletters
+
+import (
+	"time"
+
+	"github.com/muesli/beehive/bees"
+
+	"github.com/muesli/smolder"
+)
+
+// DeadLetterResponse is the common response to 'deadletter' requests
+type DeadLetterResponse struct {
+	smolder.Response
+
+	DeadLetters []deadLetterInfoResponse `json:"deadletters,omitempty"`
+	deadLetters []*bees.DeadLetter
+}
+
+type deadLetterInfoResponse struct {
+	ID        string            `json:"id"`
+	Action    string            `json:"action"`
+	Bee       string            `json:"bee"`
+	Name      string            `json:"name"`
+	Options   bees.Placeholders `json:"options"`
+	Error     string            `json:"error"`
+	Attempts  int               `json:"attempts"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// Init a new response
+func (r *DeadLetterResponse) Init(context smolder.APIContext) {
+	r.Parent = r
+	r.Context = context
+
+	r.DeadLetters = []deadLetterInfoResponse{}
+}
+
+// AddDeadLetter adds a dead letter to the response
+func (r *DeadLetterResponse) AddDeadLetter(dl *bees.DeadLetter) {
+	r.deadLetters = append(r.deadLetters, dl)
+	r.DeadLetters = append(r.DeadLetters, prepareDeadLetterResponse(r.Context, dl))
+}
+
+// EmptyResponse returns an empty API response for this endpoint if there's no data to respond with
+func (r *DeadLetterResponse) EmptyResponse() interface{} {
+	if len(r.deadLetters) == 0 {
+		var out struct {
+			DeadLetters interface{} `json:"deadletters"`
+		}
+		out.DeadLetters = []deadLetterInfoResponse{}
+		return out
+	}
+	return nil
+}
+
+func prepareDeadLetterResponse(context smolder.APIContext, dl *bees.DeadLetter) deadLetterInfoResponse {
+	resp := deadLetterInfoResponse{
+		ID:        dl.ID,
+		Action:    dl.Action.ID,
+		Bee:       dl.Action.Bee,
+		Name:      dl.Action.Name,
+		Options:   dl.Action.Options,
+		Error:     dl.Error,
+		Attempts:  dl.Attempts,
+		Timestamp: dl.Timestamp,
+	}
+
+	return resp
+}
@@ -0,0 +1,112 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package deadletters
+
+import (
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/bees"
+	"github.com/muesli/smolder"
+)
+
+// DeadLetterRetryResource is the resource responsible for /deadletters/{id}/retry
+type DeadLetterRetryResource struct {
+	smolder.Resource
+}
+
+// DeadLetterRetryStruct holds all values of an incoming POST request
+type DeadLetterRetryStruct struct {
+}
+
+// DeadLetterRetryResponse is the response to a dead letter retry request
+type DeadLetterRetryResponse struct {
+	smolder.Response
+
+	Placeholders bees.Placeholders `json:"placeholders"`
+	Error        string            `json:"error,omitempty"`
+}
+
+var (
+	_ smolder.PostSupported = &DeadLetterRetryResource{}
+)
+
+// Register this resource with the container to setup all the routes
+func (r *DeadLetterRetryResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "DeadLetterRetryResource"
+	r.TypeName = "deadletter"
+	r.Endpoint = "deadletters/{deadletter-id}/retry"
+	r.Doc = "Re-run permanently failed actions"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Reads returns the model that will be read by POST, PUT & PATCH operations
+func (r *DeadLetterRetryResource) Reads() interface{} {
+	return &DeadLetterRetryStruct{}
+}
+
+// Returns returns the model that will be returned
+func (r *DeadLetterRetryResource) Returns() interface{} {
+	return DeadLetterRetryResponse{}
+}
+
+// Validate checks an incoming request for data errors
+func (r *DeadLetterRetryResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
+	return nil
+}
+
+// PostAuthRequired returns true because all requests need authentication
+func (r *DeadLetterRetryResource) PostAuthRequired() bool {
+	return true
+}
+
+// PostDoc returns the description of this API endpoint
+func (r *DeadLetterRetryResource) PostDoc() string {
+	return "re-run the action of a dead letter"
+}
+
+// PostParams returns the parameters supported by this API endpoint
+func (r *DeadLetterRetryResource) PostParams() []*restful.Parameter {
+	return nil
+}
+
+// Post processes an incoming POST (create) request
+func (r *DeadLetterRetryResource) Post(context smolder.APIContext, data interface{}, request *restful.Request, response *restful.Response) {
+	resp := DeadLetterRetryResponse{}
+	resp.Parent = &resp
+	resp.Context = context
+
+	id := request.PathParameter("deadletter-id")
+	if bees.GetDeadLetter(id) == nil {
+		r.NotFound(request, response)
+		return
+	}
+
+	phs, err := bees.RetryDeadLetter(id)
+	resp.Placeholders = append(bees.Placeholders{}, phs...)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	resp.Send(response)
+}
@@ -0,0 +1,66 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package events
+
+import (
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/bees"
+	"github.com/muesli/smolder"
+)
+
+// EventResource is the resource responsible for /events
+type EventResource struct {
+	smolder.Resource
+}
+
+var (
+	_ smolder.PostSupported = &EventResource{}
+)
+
+// Register this resource with the container to setup all the routes
+func (r *EventResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "EventResource"
+	r.TypeName = "event"
+	r.Endpoint = "events"
+	r.Doc = "Inject events"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Reads returns the model that will be read by POST, PUT & PATCH operations
+func (r *EventResource) Reads() interface{} {
+	return &EventPostStruct{}
+}
+
+// Returns returns the model that will be returned
+func (r *EventResource) Returns() interface{} {
+	return EventResponse{}
+}
+
+// Validate checks an incoming request for data errors
+func (r *EventResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
+	ps := data.(*EventPostStruct)
+	event := ps.event()
+	return bees.ValidateEvent(&event)
+}
@@ -0,0 +1,81 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package events
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/bees"
+	"github.com/muesli/smolder"
+)
+
+// EventPostStruct holds all values of an incoming POST request
+type EventPostStruct struct {
+	Event struct {
+		Bee     string            `json:"bee"`
+		Name    string            `json:"name"`
+		Options bees.Placeholders `json:"options"`
+	} `json:"event"`
+}
+
+func (ps *EventPostStruct) event() bees.Event {
+	return bees.Event{
+		Bee:     ps.Event.Bee,
+		Name:    ps.Event.Name,
+		Options: ps.Event.Options.Normalize(),
+	}
+}
+
+// PostAuthRequired returns true because all requests need authentication
+func (r *EventResource) PostAuthRequired() bool {
+	return true
+}
+
+// PostDoc returns the description of this API endpoint
+func (r *EventResource) PostDoc() string {
+	return "inject a new event"
+}
+
+// PostParams returns the parameters supported by this API endpoint
+func (r *EventResource) PostParams() []*restful.Parameter {
+	return nil
+}
+
+// Post processes an incoming POST (create) request
+func (r *EventResource) Post(context smolder.APIContext, data interface{}, request *restful.Request, response *restful.Response) {
+	resp := EventResponse{}
+	resp.Init(context)
+
+	pps := data.(*EventPostStruct)
+	event := pps.event()
+	err := bees.InjectEvent(&event)
+	if err != nil {
+		smolder.ErrorResponseHandler(request, response, err, smolder.NewErrorResponse(
+			http.StatusServiceUnavailable,
+			err,
+			"EventResource POST"))
+		return
+	}
+
+	resp.AddEvent(&event)
+	resp.Send(response)
+}
@@ -0,0 +1,79 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package events
+
+import (
+	"github.com/muesli/beehive/bees"
+
+	"github.com/muesli/smolder"
+)
+
+// EventResponse is the common response to 'event' requests
+type EventResponse struct {
+	smolder.Response
+
+	Events []EventInfoResponse `json:"events,omitempty"`
+	events []*bees.Event
+}
+
+// EventInfoResponse is the response to a single event
+type EventInfoResponse struct {
+	Bee     string            `json:"bee"`
+	Name    string            `json:"name"`
+	Options bees.Placeholders `json:"options"`
+}
+
+// Init a new response
+func (r *EventResponse) Init(context smolder.APIContext) {
+	r.Parent = r
+	r.Context = context
+
+	r.Events = []EventInfoResponse{}
+}
+
+// AddEvent adds an event to the response
+func (r *EventResponse) AddEvent(event *bees.Event) {
+	r.events = append(r.events, event)
+	r.Events = append(r.Events, PrepareEventResponse(r.Context, event))
+}
+
+// EmptyResponse returns an empty API response for this endpoint if there's no data to respond with
+func (r *EventResponse) EmptyResponse() interface{} {
+	if len(r.events) == 0 {
+		var out struct {
+			Events interface{} `json:"events"`
+		}
+		out.Events = []EventInfoResponse{}
+		return out
+	}
+	return nil
+}
+
+// PrepareEventResponse prepares the response to a single event
+func PrepareEventResponse(context smolder.APIContext, event *bees.Event) EventInfoResponse {
+	resp := EventInfoResponse{
+		Bee:     event.Bee,
+		Name:    event.Name,
+		Options: event.Options,
+	}
+
+	return resp
+}
@@ -0,0 +1,114 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package executions
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/muesli/beehive/bees"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/smolder"
+)
+
+// GetAuthRequired returns true because all requests need authentication
+func (r *ExecutionResource) GetAuthRequired() bool {
+	return true
+}
+
+// GetByIDsAuthRequired returns true because all requests need authentication
+func (r *ExecutionResource) GetByIDsAuthRequired() bool {
+	return true
+}
+
+// GetDoc returns the description of this API endpoint
+func (r *ExecutionResource) GetDoc() string {
+	return "retrieve chain executions"
+}
+
+// GetParams returns the parameters supported by this API endpoint
+func (r *ExecutionResource) GetParams() []*restful.Parameter {
+	params := []*restful.Parameter{}
+	params = append(params, restful.QueryParameter("chain", "id of a chain").DataType("string"))
+	params = append(params, restful.QueryParameter("bee", "id of the bee that triggered the chain").DataType("string"))
+	params = append(params, restful.QueryParameter("since", "only executions started at or after this time (RFC 3339)").DataType("string"))
+	params = append(params, restful.QueryParameter("until", "only executions started at or before this time (RFC 3339)").DataType("string"))
+	params = append(params, restful.QueryParameter("limit", "maximum amount of executions to return").DataType("int"))
+
+	return params
+}
+
+// GetByIDs sends out all items matching a set of IDs
+func (r *ExecutionResource) GetByIDs(ctx smolder.APIContext, request *restful.Request, response *restful.Response, ids []string) {
+	resp := ExecutionResponse{}
+	resp.Init(ctx)
+
+	for _, id := range ids {
+		x := bees.GetExecution(id)
+		if x == nil {
+			r.NotFound(request, response)
+			return
+		}
+
+		resp.AddExecution(x)
+	}
+
+	resp.Send(response)
+}
+
+// Get sends out items matching the query parameters
+func (r *ExecutionResource) Get(ctx smolder.APIContext, request *restful.Request, response *restful.Response, params map[string][]string) {
+	q := bees.ExecutionQuery{
+		Chain: request.QueryParameter("chain"),
+		Bee:   request.QueryParameter("bee"),
+	}
+
+	var err error
+	if s := request.QueryParameter("since"); len(s) > 0 {
+		q.Since, err = time.Parse(time.RFC3339, s)
+	}
+	if s := request.QueryParameter("until"); len(s) > 0 && err == nil {
+		q.Until, err = time.Parse(time.RFC3339, s)
+	}
+	if s := request.QueryParameter("limit"); len(s) > 0 && err == nil {
+		q.Limit, err = strconv.Atoi(s)
+	}
+	if err != nil {
+		smolder.ErrorResponseHandler(request, response, err, smolder.NewErrorResponse(
+			http.StatusBadRequest,
+			errors.New("Invalid query parameter: "+err.Error()),
+			"ExecutionResource GET"))
+		return
+	}
+
+	resp := ExecutionResponse{}
+	resp.Init(ctx)
+
+	for _, x := range bees.GetExecutions(q) {
+		x := x
+		resp.AddExecution(&x)
+	}
+
+	resp.Send(response)
+}
@@ -0,0 +1,148 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package executions
+
+import (
+	"time"
+
+	"github.com/muesli/beehive/bees"
+
+	"github.com/muesli/smolder"
+)
+
+// ExecutionResponse is the common response to 'execution' requests
+type ExecutionResponse struct {
+	smolder.Response
+
+	Executions []ExecutionInfoResponse `json:"executions,omitempty"`
+	executions []*bees.Execution
+}
+
+// ExecutionInfoResponse is the response to a single execution
+type ExecutionInfoResponse struct {
+	ID        string                `json:"id"`
+	Chain     string                `json:"chain"`
+	Event     bees.Event            `json:"event"`
+	Status    string                `json:"status"`
+	Filters   []FilterTraceResponse `json:"filters"`
+	Actions   []ActionTraceResponse `json:"actions"`
+	Timestamp time.Time             `json:"timestamp"`
+	Duration  time.Duration         `json:"duration"`
+	Origin    string                `json:"origin,omitempty"`
+}
+
+// FilterTraceResponse is the response to a single filter trace
+type FilterTraceResponse struct {
+	Filter    string `json:"filter"`
+	Rendered  string `json:"rendered"`
+	Passed    bool   `json:"passed"`
+	Error     string `json:"error,omitempty"`
+	Branch    int    `json:"branch,omitempty"`
+	Transform string `json:"transform,omitempty"`
+}
+
+// ActionTraceResponse is the response to a single action trace
+type ActionTraceResponse struct {
+	ID           string            `json:"id"`
+	Bee          string            `json:"bee"`
+	Name         string            `json:"name"`
+	Options      bees.Placeholders `json:"options"`
+	Placeholders bees.Placeholders `json:"placeholders"`
+	Skipped      bool              `json:"skipped,omitempty"`
+	OnError      bool              `json:"onerror,omitempty"`
+	Scheduled    *time.Time        `json:"scheduled,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	Timestamp    time.Time         `json:"timestamp"`
+	Duration     time.Duration     `json:"duration"`
+}
+
+// Init a new response
+func (r *ExecutionResponse) Init(context smolder.APIContext) {
+	r.Parent = r
+	r.Context = context
+
+	r.Executions = []ExecutionInfoResponse{}
+}
+
+// AddExecution adds an execution to the response
+func (r *ExecutionResponse) AddExecution(x *bees.Execution) {
+	r.executions = append(r.executions, x)
+	r.Executions = append(r.Executions, PrepareExecutionResponse(r.Context, x))
+}
+
+// EmptyResponse returns an empty API response for this endpoint if there's no data to respond with
+func (r *ExecutionResponse) EmptyResponse() interface{} {
+	if len(r.executions) == 0 {
+		var out struct {
+			Executions interface{} `json:"executions"`
+		}
+		out.Executions = []ExecutionInfoResponse{}
+		return out
+	}
+	return nil
+}
+
+// PrepareExecutionResponse prepares the response to a single execution
+func PrepareExecutionResponse(context smolder.APIContext, x *bees.Execution) ExecutionInfoResponse {
+	resp := ExecutionInfoResponse{
+		ID:        x.ID,
+		Chain:     x.Chain,
+		Event:     x.Event,
+		Status:    x.Status,
+		Filters:   []FilterTraceResponse{},
+		Actions:   []ActionTraceResponse{},
+		Timestamp: x.Timestamp,
+		Duration:  x.Duration,
+		Origin:    x.Origin,
+	}
+
+	for _, f := range x.Filters {
+		resp.Filters = append(resp.Filters, FilterTraceResponse{
+			Filter:    f.Filter,
+			Rendered:  f.Rendered,
+			Passed:    f.Passed,
+			Error:     f.Error,
+			Branch:    f.Branch,
+			Transform: f.Transform,
+		})
+	}
+	for _, a := range x.Actions {
+		at := ActionTraceResponse{
+			ID:           a.ID,
+			Bee:          a.Bee,
+			Name:         a.Name,
+			Options:      a.Options,
+			Placeholders: a.Placeholders,
+			Skipped:      a.Skipped,
+			OnError:      a.OnError,
+			Error:        a.Error,
+			Timestamp:    a.Timestamp,
+			Duration:     a.Duration,
+		}
+		if !a.Scheduled.IsZero() {
+			scheduled := a.Scheduled
+			at.Scheduled = &scheduled
+		}
+		resp.Actions = append(resp.Actions, at)
+	}
+
+	return resp
+}
@@ -29,12 +29,12 @@ import (
 
 // GetAuthRequired returns true because all requests need authentication
 func (r *HiveResource) GetAuthRequired() bool {
-	return false
+	return true
 }
 
 // GetByIDsAuthRequired returns true because all requests need authentication
 func (r *HiveResource) GetByIDsAuthRequired() bool {
-	return false
+	return true
 }
 
 // GetDoc returns the description of this API endpoint
@@ -104,7 +104,7 @@ func PrepareHiveResponse(ctx smolder.APIContext, hive *bees.BeeFactoryInterface)
 		LogoColor:   (*hive).LogoColor(),
 		Options:     (*hive).Options(),
 		States:      (*hive).States(),
-		Events:      (*hive).Events(),
+		Events:      append((*hive).Events(), bees.InternalEvents((*hive).ID())...),
 		Actions:     (*hive).Actions(),
 	}
 
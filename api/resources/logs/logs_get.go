@@ -21,6 +21,11 @@
 package logs
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/muesli/beehive/bees"
 
 	"github.com/emicklei/go-restful"
@@ -29,12 +34,12 @@ import (
 
 // GetAuthRequired returns true because all requests need authentication
 func (r *LogResource) GetAuthRequired() bool {
-	return false
+	return true
 }
 
 // GetByIDsAuthRequired returns true because all requests need authentication
 func (r *LogResource) GetByIDsAuthRequired() bool {
-	return false
+	return true
 }
 
 // GetDoc returns the description of this API endpoint
@@ -46,6 +51,11 @@ func (r *LogResource) GetDoc() string {
 func (r *LogResource) GetParams() []*restful.Parameter {
 	params := []*restful.Parameter{}
 	params = append(params, restful.QueryParameter("bee", "id of a bee").DataType("string"))
+	params = append(params, restful.QueryParameter("level", "minimum log level (debug, info, error, fatal)").DataType("string"))
+	params = append(params, restful.QueryParameter("since", "only logs after this time (RFC3339)").DataType("string"))
+	params = append(params, restful.QueryParameter("until", "only logs before this time (RFC3339)").DataType("string"))
+	params = append(params, restful.QueryParameter("limit", "maximum amount of logs to return").DataType("int"))
+	params = append(params, restful.QueryParameter("cursor", "continue a previous, limited query").DataType("string"))
 
 	return params
 }
@@ -63,13 +73,45 @@ func (r *LogResource) GetByIDs(ctx smolder.APIContext, request *restful.Request,
 // Get sends out items matching the query parameters
 func (r *LogResource) Get(ctx smolder.APIContext, request *restful.Request, response *restful.Response, params map[string][]string) {
 	//	ctxapi := ctx.(*context.APIContext)
-	bee := request.QueryParameter("bee")
+	q := bees.LogQuery{
+		Bee:    request.QueryParameter("bee"),
+		Cursor: request.QueryParameter("cursor"),
+	}
+
+	var err error
+	if s := request.QueryParameter("level"); len(s) > 0 {
+		var level bees.MessageType
+		level, err = bees.ParseLogLevel(s)
+		q.Level = &level
+	}
+	if s := request.QueryParameter("since"); len(s) > 0 && err == nil {
+		q.Since, err = time.Parse(time.RFC3339, s)
+	}
+	if s := request.QueryParameter("until"); len(s) > 0 && err == nil {
+		q.Until, err = time.Parse(time.RFC3339, s)
+	}
+	if s := request.QueryParameter("limit"); len(s) > 0 && err == nil {
+		q.Limit, err = strconv.Atoi(s)
+	}
+
+	var logs []bees.LogMessage
+	if err == nil {
+		logs, q.Cursor, err = bees.QueryLogs(q)
+	}
+	if err != nil {
+		smolder.ErrorResponseHandler(request, response, err, smolder.NewErrorResponse(
+			http.StatusBadRequest,
+			errors.New("Invalid query parameter: "+err.Error()),
+			"LogResource GET"))
+		return
+	}
 
 	resp := LogResponse{}
 	resp.Init(ctx)
+	resp.Cursor = q.Cursor
 
-	logs := bees.GetLogs(bee)
 	for _, log := range logs {
+		log := log
 		resp.AddLog(&log)
 	}
 
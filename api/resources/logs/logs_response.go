@@ -32,11 +32,13 @@ import (
 type LogResponse struct {
 	smolder.Response
 
-	Logs []logInfoResponse `json:"logs,omitempty"`
-	logs []*bees.LogMessage
+	Logs   []LogInfoResponse `json:"logs,omitempty"`
+	Cursor string            `json:"cursor,omitempty"`
+	logs   []*bees.LogMessage
 }
 
-type logInfoResponse struct {
+// LogInfoResponse is the response to a single log message
+type LogInfoResponse struct {
 	ID        string    `json:"id"`
 	Bee       string    `json:"bee"`
 	Level     int64     `json:"level"`
@@ -49,13 +51,13 @@ func (r *LogResponse) Init(context smolder.APIContext) {
 	r.Parent = r
 	r.Context = context
 
-	r.Logs = []logInfoResponse{}
+	r.Logs = []LogInfoResponse{}
 }
 
 // AddLog adds a log to the response
 func (r *LogResponse) AddLog(log *bees.LogMessage) {
 	r.logs = append(r.logs, log)
-	r.Logs = append(r.Logs, prepareLogResponse(r.Context, log))
+	r.Logs = append(r.Logs, PrepareLogResponse(r.Context, log))
 }
 
 // EmptyResponse returns an empty API response for this endpoint if there's no data to respond with
@@ -64,15 +66,16 @@ func (r *LogResponse) EmptyResponse() interface{} {
 		var out struct {
 			Logs interface{} `json:"logs"`
 		}
-		out.Logs = []logInfoResponse{}
+		out.Logs = []LogInfoResponse{}
 		return out
 	}
 	return nil
 }
 
-func prepareLogResponse(context smolder.APIContext, log *bees.LogMessage) logInfoResponse {
+// PrepareLogResponse prepares the response to a single log message
+func PrepareLogResponse(context smolder.APIContext, log *bees.LogMessage) LogInfoResponse {
 	//	ctx := context.(*context.APIContext)
-	resp := logInfoResponse{
+	resp := LogInfoResponse{
 		ID:        (*log).ID,
 		Bee:       (*log).Bee,
 		Level:     int64((*log).MessageType),
@@ -0,0 +1,114 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package scheduledactions
+
+import (
+	"errors"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/bees"
+	"github.com/muesli/smolder"
+)
+
+// ScheduledActionCancelResource is the resource responsible for
+// /scheduledactions/cancel
+type ScheduledActionCancelResource struct {
+	smolder.Resource
+}
+
+// ScheduledActionCancelStruct holds all values of an incoming POST request
+type ScheduledActionCancelStruct struct {
+	Cancel struct {
+		Action string `json:"action"`
+		Key    string `json:"key"`
+	} `json:"cancel"`
+}
+
+// ScheduledActionCancelResponse is the response to a cancel request
+type ScheduledActionCancelResponse struct {
+	smolder.Response
+
+	Cancelled int `json:"cancelled"`
+}
+
+var (
+	_ smolder.PostSupported = &ScheduledActionCancelResource{}
+)
+
+// Register this resource with the container to setup all the routes
+func (r *ScheduledActionCancelResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "ScheduledActionCancelResource"
+	r.TypeName = "scheduledaction"
+	r.Endpoint = "scheduledactions/cancel"
+	r.Doc = "Cancel pending scheduled actions by key"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Reads returns the model that will be read by POST, PUT & PATCH operations
+func (r *ScheduledActionCancelResource) Reads() interface{} {
+	return &ScheduledActionCancelStruct{}
+}
+
+// Returns returns the model that will be returned
+func (r *ScheduledActionCancelResource) Returns() interface{} {
+	return ScheduledActionCancelResponse{}
+}
+
+// Validate checks an incoming request for data errors
+func (r *ScheduledActionCancelResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
+	ps := data.(*ScheduledActionCancelStruct)
+	if len(ps.Cancel.Action) == 0 {
+		return errors.New("Missing action")
+	}
+
+	return nil
+}
+
+// PostAuthRequired returns true because all requests need authentication
+func (r *ScheduledActionCancelResource) PostAuthRequired() bool {
+	return true
+}
+
+// PostDoc returns the description of this API endpoint
+func (r *ScheduledActionCancelResource) PostDoc() string {
+	return "cancel all pending executions of an action with a specific key"
+}
+
+// PostParams returns the parameters supported by this API endpoint
+func (r *ScheduledActionCancelResource) PostParams() []*restful.Parameter {
+	return nil
+}
+
+// Post processes an incoming POST (create) request
+func (r *ScheduledActionCancelResource) Post(context smolder.APIContext, data interface{}, request *restful.Request, response *restful.Response) {
+	resp := ScheduledActionCancelResponse{}
+	resp.Parent = &resp
+	resp.Context = context
+
+	ps := data.(*ScheduledActionCancelStruct)
+	resp.Cancelled = bees.CancelScheduledActions(ps.Cancel.Action, ps.Cancel.Key)
+
+	resp.Send(response)
+}
@@ -0,0 +1,93 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package scheduledactions
+
+import (
+	"github.com/muesli/beehive/bees"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/smolder"
+)
+
+// GetAuthRequired returns true because all requests need authentication
+func (r *ScheduledActionResource) GetAuthRequired() bool {
+	return true
+}
+
+// GetByIDsAuthRequired returns true because all requests need authentication
+func (r *ScheduledActionResource) GetByIDsAuthRequired() bool {
+	return true
+}
+
+// GetDoc returns the description of this API endpoint
+func (r *ScheduledActionResource) GetDoc() string {
+	return "retrieve pending scheduled actions"
+}
+
+// GetParams returns the parameters supported by this API endpoint
+func (r *ScheduledActionResource) GetParams() []*restful.Parameter {
+	params := []*restful.Parameter{}
+	params = append(params, restful.QueryParameter("chain", "id of a chain").DataType("string"))
+	params = append(params, restful.QueryParameter("bee", "id of a bee").DataType("string"))
+
+	return params
+}
+
+// GetByIDs sends out all items matching a set of IDs
+func (r *ScheduledActionResource) GetByIDs(ctx smolder.APIContext, request *restful.Request, response *restful.Response, ids []string) {
+	resp := ScheduledActionResponse{}
+	resp.Init(ctx)
+
+	for _, id := range ids {
+		sa := bees.GetScheduledAction(id)
+		if sa == nil {
+			r.NotFound(request, response)
+			return
+		}
+
+		resp.AddScheduledAction(sa)
+	}
+
+	resp.Send(response)
+}
+
+// Get sends out items matching the query parameters
+func (r *ScheduledActionResource) Get(ctx smolder.APIContext, request *restful.Request, response *restful.Response, params map[string][]string) {
+	chain := request.QueryParameter("chain")
+	bee := request.QueryParameter("bee")
+
+	resp := ScheduledActionResponse{}
+	resp.Init(ctx)
+
+	for _, sa := range bees.GetScheduledActions() {
+		if len(chain) > 0 && sa.Chain != chain {
+			continue
+		}
+		if len(bee) > 0 && sa.Action.Bee != bee {
+			continue
+		}
+
+		sa := sa
+		resp.AddScheduledAction(&sa)
+	}
+
+	resp.Send(response)
+}
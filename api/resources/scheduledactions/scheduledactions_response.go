@@ -0,0 +1,93 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package scheduledactions
+
+import (
+	"time"
+
+	"github.com/muesli/beehive/bees"
+
+	"github.com/muesli/smolder"
+)
+
+// ScheduledActionResponse is the common response to 'scheduledaction' requests
+type ScheduledActionResponse struct {
+	smolder.Response
+
+	ScheduledActions []scheduledActionInfoResponse `json:"scheduledactions,omitempty"`
+	scheduledActions []*bees.ScheduledAction
+}
+
+type scheduledActionInfoResponse struct {
+	ID        string            `json:"id"`
+	Chain     string            `json:"chain"`
+	Execution string            `json:"execution,omitempty"`
+	Action    string            `json:"action"`
+	Bee       string            `json:"bee"`
+	Name      string            `json:"name"`
+	Options   bees.Placeholders `json:"options"`
+	Key       string            `json:"key,omitempty"`
+	Due       time.Time         `json:"due"`
+	Created   time.Time         `json:"created"`
+}
+
+// Init a new response
+func (r *ScheduledActionResponse) Init(context smolder.APIContext) {
+	r.Parent = r
+	r.Context = context
+
+	r.ScheduledActions = []scheduledActionInfoResponse{}
+}
+
+// AddScheduledAction adds a scheduled action to the response
+func (r *ScheduledActionResponse) AddScheduledAction(sa *bees.ScheduledAction) {
+	r.scheduledActions = append(r.scheduledActions, sa)
+	r.ScheduledActions = append(r.ScheduledActions, prepareScheduledActionResponse(r.Context, sa))
+}
+
+// EmptyResponse returns an empty API response for this endpoint if there's no data to respond with
+func (r *ScheduledActionResponse) EmptyResponse() interface{} {
+	if len(r.scheduledActions) == 0 {
+		var out struct {
+			ScheduledActions interface{} `json:"scheduledactions"`
+		}
+		out.ScheduledActions = []scheduledActionInfoResponse{}
+		return out
+	}
+	return nil
+}
+
+func prepareScheduledActionResponse(context smolder.APIContext, sa *bees.ScheduledAction) scheduledActionInfoResponse {
+	resp := scheduledActionInfoResponse{
+		ID:        sa.ID,
+		Chain:     sa.Chain,
+		Execution: sa.Execution,
+		Action:    sa.Action.ID,
+		Bee:       sa.Action.Bee,
+		Name:      sa.Action.Name,
+		Options:   sa.Action.Options,
+		Key:       sa.Key,
+		Due:       sa.Due,
+		Created:   sa.Created,
+	}
+
+	return resp
+}
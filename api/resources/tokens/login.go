@@ -0,0 +1,122 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package tokens
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/api/auth"
+	"github.com/muesli/beehive/api/context"
+	"github.com/muesli/smolder"
+)
+
+// LoginResource is the resource responsible for /login
+type LoginResource struct {
+	smolder.Resource
+}
+
+// LoginPostStruct holds all values of an incoming login request
+type LoginPostStruct struct {
+	Token string `json:"token"`
+}
+
+var (
+	_ smolder.PostSupported = &LoginResource{}
+)
+
+// Register this resource with the container to setup all the routes
+func (r *LoginResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "LoginResource"
+	r.TypeName = "login"
+	r.Endpoint = "login"
+	r.Doc = "Log in to the admin interface"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Reads returns the model that will be read by POST, PUT & PATCH operations
+func (r *LoginResource) Reads() interface{} {
+	return &LoginPostStruct{}
+}
+
+// Returns returns the model that will be returned
+func (r *LoginResource) Returns() interface{} {
+	return TokenResponse{}
+}
+
+// Validate checks an incoming request for data errors
+func (r *LoginResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
+	return nil
+}
+
+// PostAuthRequired returns false, as this is where tokens get checked
+func (r *LoginResource) PostAuthRequired() bool {
+	return false
+}
+
+// PostDoc returns the description of this API endpoint
+func (r *LoginResource) PostDoc() string {
+	return "log in with an access token"
+}
+
+// PostParams returns the parameters supported by this API endpoint
+func (r *LoginResource) PostParams() []*restful.Parameter {
+	return nil
+}
+
+// Post checks the supplied token and stores it in a cookie, which
+// authenticates all further requests made by the admin interface
+func (r *LoginResource) Post(ctx smolder.APIContext, data interface{}, request *restful.Request, response *restful.Response) {
+	resp := TokenResponse{}
+	resp.Init(ctx)
+
+	if !auth.Enabled() {
+		resp.AddToken(auth.Anonymous)
+		resp.Send(response)
+		return
+	}
+
+	pps := data.(*LoginPostStruct)
+	token := auth.Authenticate(pps.Token)
+	if token == nil {
+		smolder.ErrorResponseHandler(request, response, nil, smolder.NewErrorResponse(
+			http.StatusUnauthorized,
+			"Invalid accesstoken",
+			"LoginResource POST"))
+		return
+	}
+
+	http.SetCookie(response.ResponseWriter, &http.Cookie{
+		Name:     context.TokenCookie,
+		Value:    pps.Token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   request.Request.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	resp.AddToken(token)
+	resp.Send(response)
+}
@@ -0,0 +1,68 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package tokens
+
+import (
+	"errors"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/smolder"
+)
+
+// TokenResource is the resource responsible for /tokens
+type TokenResource struct {
+	smolder.Resource
+}
+
+var (
+	_ smolder.GetIDSupported  = &TokenResource{}
+	_ smolder.GetSupported    = &TokenResource{}
+	_ smolder.PostSupported   = &TokenResource{}
+	_ smolder.DeleteSupported = &TokenResource{}
+)
+
+// Register this resource with the container to setup all the routes
+func (r *TokenResource) Register(container *restful.Container, config smolder.APIConfig, context smolder.APIContextFactory) {
+	r.Name = "TokenResource"
+	r.TypeName = "token"
+	r.Endpoint = "tokens"
+	r.Doc = "Manage API access tokens"
+
+	r.Config = config
+	r.Context = context
+
+	r.Init(container, r)
+}
+
+// Returns returns the model that will be returned
+func (r *TokenResource) Returns() interface{} {
+	return TokenResponse{}
+}
+
+// Validate checks an incoming request for data errors
+func (r *TokenResource) Validate(context smolder.APIContext, data interface{}, request *restful.Request) error {
+	ps := data.(*TokenPostStruct)
+	if len(ps.Token.Name) == 0 {
+		return errors.New("A token needs a name")
+	}
+
+	return nil
+}
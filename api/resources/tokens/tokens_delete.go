@@ -0,0 +1,56 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package tokens
+
+import (
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/api/auth"
+	"github.com/muesli/smolder"
+)
+
+// DeleteAuthRequired returns true because all requests need authentication
+func (r *TokenResource) DeleteAuthRequired() bool {
+	return true
+}
+
+// DeleteDoc returns the description of this API endpoint
+func (r *TokenResource) DeleteDoc() string {
+	return "revoke a token"
+}
+
+// DeleteParams returns the parameters supported by this API endpoint
+func (r *TokenResource) DeleteParams() []*restful.Parameter {
+	return nil
+}
+
+// Delete processes an incoming DELETE request
+func (r *TokenResource) Delete(context smolder.APIContext, request *restful.Request, response *restful.Response) {
+	resp := TokenResponse{}
+	resp.Init(context)
+
+	id := request.PathParameter("token-id")
+	if !auth.DeleteToken(id) {
+		r.NotFound(request, response)
+		return
+	}
+
+	resp.Send(response)
+}
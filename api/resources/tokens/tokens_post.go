@@ -0,0 +1,90 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package tokens
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/beehive/api/auth"
+	"github.com/muesli/smolder"
+)
+
+// TokenPostStruct holds all values of an incoming POST request
+type TokenPostStruct struct {
+	Token struct {
+		Name  string `json:"name"`
+		Scope string `json:"scope"`
+	} `json:"token"`
+}
+
+// PostAuthRequired returns true because all requests need authentication
+func (r *TokenResource) PostAuthRequired() bool {
+	return true
+}
+
+// PostDoc returns the description of this API endpoint
+func (r *TokenResource) PostDoc() string {
+	return "create a new token"
+}
+
+// PostParams returns the parameters supported by this API endpoint
+func (r *TokenResource) PostParams() []*restful.Parameter {
+	return nil
+}
+
+// Reads returns the model that will be read by POST, PUT & PATCH operations
+func (r *TokenResource) Reads() interface{} {
+	return &TokenPostStruct{}
+}
+
+// Post processes an incoming POST (create) request
+func (r *TokenResource) Post(context smolder.APIContext, data interface{}, request *restful.Request, response *restful.Response) {
+	resp := TokenResponse{}
+	resp.Init(context)
+
+	pps := data.(*TokenPostStruct)
+	if !auth.ValidScope(pps.Token.Scope) {
+		err := errors.New("Unknown scope '" + pps.Token.Scope + "', valid scopes are: " +
+			strings.Join(auth.Scopes(), ", "))
+		smolder.ErrorResponseHandler(request, response, nil, smolder.NewErrorResponse(
+			422, // Go 1.7+: http.StatusUnprocessableEntity,
+			err,
+			"TokenResource POST"))
+		return
+	}
+
+	token, secret, err := auth.CreateToken(pps.Token.Name, pps.Token.Scope)
+	if err != nil {
+		smolder.ErrorResponseHandler(request, response, err, smolder.NewErrorResponse(
+			http.StatusInternalServerError,
+			err,
+			"TokenResource POST"))
+		return
+	}
+
+	// the secret is only ever sent out once, right after its creation
+	resp.AddToken(&token)
+	resp.Tokens[0].Secret = secret
+	resp.Send(response)
+}
@@ -0,0 +1,82 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package tokens
+
+import (
+	"time"
+
+	"github.com/muesli/beehive/api/auth"
+
+	"github.com/muesli/smolder"
+)
+
+// TokenResponse is the common response to 'token' requests
+type TokenResponse struct {
+	smolder.Response
+
+	Tokens []tokenInfoResponse `json:"tokens,omitempty"`
+	tokens []*auth.Token
+}
+
+type tokenInfoResponse struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Scope   string    `json:"scope"`
+	Created time.Time `json:"created"`
+	Secret  string    `json:"secret,omitempty"`
+}
+
+// Init a new response
+func (r *TokenResponse) Init(context smolder.APIContext) {
+	r.Parent = r
+	r.Context = context
+
+	r.Tokens = []tokenInfoResponse{}
+}
+
+// AddToken adds a token to the response
+func (r *TokenResponse) AddToken(token *auth.Token) {
+	r.tokens = append(r.tokens, token)
+	r.Tokens = append(r.Tokens, prepareTokenResponse(r.Context, token))
+}
+
+// EmptyResponse returns an empty API response for this endpoint if there's no data to respond with
+func (r *TokenResponse) EmptyResponse() interface{} {
+	if len(r.tokens) == 0 {
+		var out struct {
+			Tokens interface{} `json:"tokens"`
+		}
+		out.Tokens = []tokenInfoResponse{}
+		return out
+	}
+	return nil
+}
+
+func prepareTokenResponse(context smolder.APIContext, token *auth.Token) tokenInfoResponse {
+	resp := tokenInfoResponse{
+		ID:      token.ID,
+		Name:    token.Name,
+		Scope:   token.Scope,
+		Created: token.Created,
+	}
+
+	return resp
+}
@@ -0,0 +1,79 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package workqueues
+
+import (
+	"github.com/muesli/beehive/bees"
+
+	"github.com/emicklei/go-restful"
+	"github.com/muesli/smolder"
+)
+
+// GetAuthRequired returns true because all requests need authentication
+func (r *WorkQueueResource) GetAuthRequired() bool {
+	return true
+}
+
+// GetByIDsAuthRequired returns true because all requests need authentication
+func (r *WorkQueueResource) GetByIDsAuthRequired() bool {
+	return true
+}
+
+// GetDoc returns the description of this API endpoint
+func (r *WorkQueueResource) GetDoc() string {
+	return "retrieve the depth of all work queues"
+}
+
+// GetParams returns the parameters supported by this API endpoint
+func (r *WorkQueueResource) GetParams() []*restful.Parameter {
+	return nil
+}
+
+// GetByIDs sends out all items matching a set of IDs
+func (r *WorkQueueResource) GetByIDs(ctx smolder.APIContext, request *restful.Request, response *restful.Response, ids []string) {
+	resp := WorkQueueResponse{}
+	resp.Init(ctx)
+
+	for _, id := range ids {
+		q := bees.GetWorkQueue(id)
+		if q == nil {
+			r.NotFound(request, response)
+			return
+		}
+
+		resp.AddWorkQueue(q)
+	}
+
+	resp.Send(response)
+}
+
+// Get sends out items matching the query parameters
+func (r *WorkQueueResource) Get(ctx smolder.APIContext, request *restful.Request, response *restful.Response, params map[string][]string) {
+	resp := WorkQueueResponse{}
+	resp.Init(ctx)
+
+	for _, q := range bees.GetWorkQueues() {
+		q := q
+		resp.AddWorkQueue(&q)
+	}
+
+	resp.Send(response)
+}
@@ -0,0 +1,85 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package workqueues
+
+import (
+	"github.com/muesli/beehive/bees"
+
+	"github.com/muesli/smolder"
+)
+
+// WorkQueueResponse is the common response to 'workqueue' requests
+type WorkQueueResponse struct {
+	smolder.Response
+
+	WorkQueues []workQueueInfoResponse `json:"workqueues,omitempty"`
+	workQueues []*bees.WorkQueueStats
+}
+
+type workQueueInfoResponse struct {
+	ID        string `json:"id"`
+	Ordering  string `json:"ordering"`
+	Workers   int    `json:"workers"`
+	Active    int    `json:"active"`
+	Queued    int    `json:"queued"`
+	Capacity  int    `json:"capacity"`
+	Processed uint64 `json:"processed"`
+}
+
+// Init a new response
+func (r *WorkQueueResponse) Init(context smolder.APIContext) {
+	r.Parent = r
+	r.Context = context
+
+	r.WorkQueues = []workQueueInfoResponse{}
+}
+
+// AddWorkQueue adds a work queue to the response
+func (r *WorkQueueResponse) AddWorkQueue(q *bees.WorkQueueStats) {
+	r.workQueues = append(r.workQueues, q)
+	r.WorkQueues = append(r.WorkQueues, prepareWorkQueueResponse(r.Context, q))
+}
+
+// EmptyResponse returns an empty API response for this endpoint if there's no data to respond with
+func (r *WorkQueueResponse) EmptyResponse() interface{} {
+	if len(r.workQueues) == 0 {
+		var out struct {
+			WorkQueues interface{} `json:"workqueues"`
+		}
+		out.WorkQueues = []workQueueInfoResponse{}
+		return out
+	}
+	return nil
+}
+
+func prepareWorkQueueResponse(context smolder.APIContext, q *bees.WorkQueueStats) workQueueInfoResponse {
+	resp := workQueueInfoResponse{
+		ID:        q.ID,
+		Ordering:  q.Ordering,
+		Workers:   q.Workers,
+		Active:    q.Active,
+		Queued:    q.Queued,
+		Capacity:  q.Capacity,
+		Processed: q.Processed,
+	}
+
+	return resp
+}
@@ -0,0 +1,218 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/gorilla/websocket"
+	"github.com/muesli/smolder"
+	log "github.com/sirupsen/logrus"
+
+	bee "github.com/muesli/beehive/bees"
+
+	"github.com/muesli/beehive/api/resources/events"
+	"github.com/muesli/beehive/api/resources/executions"
+	"github.com/muesli/beehive/api/resources/logs"
+)
+
+// interval in which we send keep-alives to idle stream clients
+const streamKeepAlive = 15 * time.Second
+
+// streamMessageResponse is a single message sent to stream clients
+type streamMessageResponse struct {
+	Type      string                            `json:"type"`
+	Event     *events.EventInfoResponse         `json:"event,omitempty"`
+	Execution *executions.ExecutionInfoResponse `json:"execution,omitempty"`
+	Log       *logs.LogInfoResponse             `json:"log,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{}
+
+// streamHandler returns the handler for /v1/stream, which pushes events,
+// chain executions and log messages to its clients in real time. Clients
+// can either connect via WebSocket or as a Server-Sent Events stream.
+func streamHandler(contextFactory smolder.APIContextFactory) restful.RouteFunction {
+	return func(req *restful.Request, resp *restful.Response) {
+		ctx := contextFactory.NewAPIContext()
+		if auth, err := ctx.Authentication(req); err != nil || auth == nil {
+			http.Error(resp.ResponseWriter, "Invalid accesstoken", http.StatusUnauthorized)
+			return
+		}
+
+		filter, err := parseStreamFilter(req)
+		if err != nil {
+			http.Error(resp.ResponseWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sub := bee.Subscribe(filter)
+		defer bee.Unsubscribe(sub)
+
+		if websocket.IsWebSocketUpgrade(req.Request) {
+			streamWebSocket(ctx, req, resp, sub)
+		} else {
+			streamSSE(ctx, req, resp, sub)
+		}
+	}
+}
+
+func parseStreamFilter(req *restful.Request) (bee.StreamFilter, error) {
+	filter := bee.StreamFilter{
+		Bee:   req.QueryParameter("bee"),
+		Event: req.QueryParameter("event"),
+	}
+
+	if t := req.QueryParameter("types"); len(t) > 0 {
+		for _, s := range strings.Split(t, ",") {
+			s = strings.TrimSpace(s)
+			switch s {
+			case bee.StreamEvent, bee.StreamExecution, bee.StreamLog:
+				filter.Types = append(filter.Types, s)
+			default:
+				return filter, fmt.Errorf("Unknown stream type %s", s)
+			}
+		}
+	}
+
+	if l := req.QueryParameter("level"); len(l) > 0 {
+		level, err := bee.ParseLogLevel(l)
+		if err != nil {
+			return filter, err
+		}
+		filter.Level = &level
+	}
+
+	return filter, nil
+}
+
+func prepareStreamMessage(ctx smolder.APIContext, m bee.StreamMessage) streamMessageResponse {
+	r := streamMessageResponse{Type: m.Type}
+
+	switch m.Type {
+	case bee.StreamEvent:
+		e := events.PrepareEventResponse(ctx, m.Event)
+		r.Event = &e
+	case bee.StreamExecution:
+		x := executions.PrepareExecutionResponse(ctx, m.Execution)
+		r.Execution = &x
+	case bee.StreamLog:
+		l := logs.PrepareLogResponse(ctx, m.Log)
+		r.Log = &l
+	}
+
+	return r
+}
+
+func streamSSE(ctx smolder.APIContext, req *restful.Request, resp *restful.Response, sub *bee.Subscription) {
+	flusher, ok := resp.ResponseWriter.(http.Flusher)
+	if !ok {
+		http.Error(resp.ResponseWriter, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w := resp.ResponseWriter
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-req.Request.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			b, err := json.Marshal(prepareStreamMessage(ctx, m))
+			if err != nil {
+				log.Errorln("Failed encoding stream message:", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", m.Type, b); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func streamWebSocket(ctx smolder.APIContext, req *restful.Request, resp *restful.Response, sub *bee.Subscription) {
+	conn, err := upgrader.Upgrade(resp.ResponseWriter, req.Request, nil)
+	if err != nil {
+		log.Errorln("WebSocket upgrade failed:", err)
+		return
+	}
+	defer conn.Close()
+
+	// we don't expect any messages from the client, but have to read in
+	// order to notice when it goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+
+		case <-keepAlive.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamKeepAlive))
+			if err != nil {
+				return
+			}
+
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(prepareStreamMessage(ctx, m)); err != nil {
+				return
+			}
+		}
+	}
+}
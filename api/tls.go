@@ -0,0 +1,254 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/muesli/beehive/app"
+	"github.com/muesli/beehive/cfg"
+)
+
+var (
+	tlsEnabled  bool
+	tlsCert     string
+	tlsKey      string
+	tlsClientCA string
+)
+
+// TLSEnabled returns true if the API is served via HTTPS
+func TLSEnabled() bool {
+	return tlsEnabled || len(tlsCert) > 0 || len(tlsKey) > 0 || len(tlsClientCA) > 0
+}
+
+// tlsPaths returns the certificate & key files to use. Unless specified on
+// the command-line, they get stored next to the default config file.
+func tlsPaths() (string, string) {
+	dir := filepath.Dir(cfg.DefaultPath())
+
+	cert := tlsCert
+	if len(cert) == 0 {
+		cert = filepath.Join(dir, "beehive.crt")
+	}
+	key := tlsKey
+	if len(key) == 0 {
+		key = filepath.Join(dir, "beehive.key")
+	}
+
+	return cert, key
+}
+
+// tlsConfig prepares the TLS configuration of the API server, generating a
+// self-signed certificate if none exists yet.
+func tlsConfig() (*tls.Config, string, string, error) {
+	cert, key := tlsPaths()
+
+	_, cerr := os.Stat(cert)
+	_, kerr := os.Stat(key)
+	if os.IsNotExist(cerr) && os.IsNotExist(kerr) {
+		log.Infof("Generating self-signed TLS certificate %s", cert)
+		if err := generateCertificate(cert, key, tlsHosts()); err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if len(tlsClientCA) > 0 {
+		b, err := ioutil.ReadFile(tlsClientCA)
+		if err != nil {
+			return nil, "", "", err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, "", "", errors.New("No certificates found in " + tlsClientCA)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, cert, key, nil
+}
+
+// tlsHosts returns the host names & addresses a generated certificate is
+// valid for.
+func tlsHosts() []string {
+	candidates := []string{"localhost", "127.0.0.1", "::1"}
+	if u := CanonicalURL(); u != nil {
+		candidates = append(candidates, u.Hostname())
+	}
+	if host, _, err := net.SplitHostPort(bind); err == nil {
+		candidates = append(candidates, host)
+	}
+
+	hosts := []string{}
+	seen := map[string]bool{}
+	for _, h := range candidates {
+		if len(h) == 0 || seen[h] {
+			continue
+		}
+		seen[h] = true
+		hosts = append(hosts, h)
+	}
+
+	return hosts
+}
+
+// generateCertificate creates a self-signed certificate for hosts and stores
+// it and its private key as PEM files.
+func generateCertificate(certFile, keyFile string, hosts []string) error {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Beehive"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0700); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+}
+
+// Ping checks whether the API is up and serving requests. When client
+// certificates are required, we can't complete a request ourselves, so only
+// the listener gets checked.
+func Ping() error {
+	u := CanonicalURL()
+	if u == nil {
+		return errors.New("Invalid canonical URL")
+	}
+
+	if len(tlsClientCA) > 0 {
+		conn, err := net.DialTimeout("tcp", bind, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			// we're only checking on ourselves, which commonly uses a
+			// self-signed certificate
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// secureCanonicalURL switches the default canonical URL to HTTPS.
+func secureCanonicalURL() {
+	if canonicalURL == defaultURL {
+		canonicalURL = "https://" + strings.TrimPrefix(defaultURL, "http://")
+	}
+}
+
+func init() {
+	app.AddFlags([]app.CliFlag{
+		{
+			V:     &tlsEnabled,
+			Name:  "tls",
+			Value: false,
+			Desc:  "Serve the API & admin interface via HTTPS, using a self-signed certificate unless specified",
+		},
+		{
+			V:     &tlsCert,
+			Name:  "tls-cert",
+			Value: "",
+			Desc:  "TLS certificate file for the API & admin interface (generated if missing)",
+		},
+		{
+			V:     &tlsKey,
+			Name:  "tls-key",
+			Value: "",
+			Desc:  "TLS private key file for the API & admin interface (generated if missing)",
+		},
+		{
+			V:     &tlsClientCA,
+			Name:  "tls-client-ca",
+			Value: "",
+			Desc:  "Require client certificates signed by the CAs in this file",
+		},
+	})
+}
@@ -29,12 +29,14 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/mattn/go-colorable"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/muesli/beehive/api"
+	"github.com/muesli/beehive/api/auth"
 	"github.com/muesli/beehive/app"
 	"github.com/muesli/beehive/cfg"
 	_ "github.com/muesli/beehive/filters"
@@ -48,6 +50,13 @@ var (
 	versionFlag bool
 	debugFlag   bool
 	decryptFlag bool
+	queueFlag   bool
+	logsFlag    bool
+	logsPerBee  int
+	workers     int
+	workQueue   int
+	ordering    string
+	orderingKey string
 )
 
 func main() {
@@ -76,6 +85,48 @@ func main() {
 			Value: false,
 			Desc:  "Decrypt and print the configuration file",
 		},
+		{
+			V:     &queueFlag,
+			Name:  "eventqueue",
+			Value: false,
+			Desc:  "Persist incoming events on disk until all chains handled them",
+		},
+		{
+			V:     &logsFlag,
+			Name:  "persistentlogs",
+			Value: false,
+			Desc:  "Store the bees' log messages on disk, so they survive restarts",
+		},
+		{
+			V:     &logsPerBee,
+			Name:  "logsperbee",
+			Value: bees.DefaultLogsPerBee,
+			Desc:  "Maximum amount of log messages kept per bee",
+		},
+		{
+			V:     &workers,
+			Name:  "workers",
+			Value: bees.DefaultWorkers,
+			Desc:  "Amount of events processed concurrently",
+		},
+		{
+			V:     &workQueue,
+			Name:  "workqueue",
+			Value: bees.DefaultWorkQueueSize,
+			Desc:  "Amount of events buffered before bees get blocked",
+		},
+		{
+			V:     &ordering,
+			Name:  "ordering",
+			Value: bees.OrderingNone,
+			Desc:  "Process events in order: none, bee or key",
+		},
+		{
+			V:     &orderingKey,
+			Name:  "orderingkey",
+			Value: "",
+			Desc:  "Template deriving the key events get ordered by",
+		},
 	})
 
 	// Parse command-line args for all registered bees
@@ -126,12 +177,57 @@ func main() {
 		}
 	}
 
+	if logsFlag {
+		path := dataPath(config, "logs")
+		s, err := bees.NewDiskLogStore(path, logsPerBee)
+		if err != nil {
+			log.Fatalf("Error opening the log store in %s. err: %v", path, err)
+		}
+		log.Infof("Persisting logs in %s", path)
+		bees.SetLogStore(s)
+		defer s.Close()
+	} else {
+		bees.SetLogStore(bees.NewMemoryLogStore(logsPerBee))
+	}
+
+	if queueFlag {
+		path := dataPath(config, "queue")
+		q, err := bees.NewDiskQueue(path)
+		if err != nil {
+			log.Fatalf("Error opening the event queue in %s. err: %v", path, err)
+		}
+		log.Infof("Persisting events in %s", path)
+		bees.SetEventQueue(q)
+		defer q.Close()
+	}
+
+	err = bees.SetWorkerPool(bees.WorkerPoolConfig{
+		Workers:   workers,
+		QueueSize: workQueue,
+		Ordering:  ordering,
+		Key:       orderingKey,
+	})
+	if err != nil {
+		log.Fatalf("Error configuring the worker pool. err: %v", err)
+	}
+
+	// Load API tokens from config
+	auth.SetTokens(config.Tokens)
+	if !auth.Enabled() {
+		log.Warnln("No API tokens configured, the API is accessible without authentication!")
+	}
 	// Load actions from config
 	bees.SetActions(config.Actions)
 	// Load chains from config
 	bees.SetChains(config.Chains)
 	// Initialize bees
 	bees.StartBees(config.Bees)
+	// Restore pending scheduled actions
+	path := dataPath(config, "scheduled.json")
+	err = bees.SetSchedulePath(path)
+	if err != nil {
+		log.Errorf("Error restoring scheduled actions from %s. err: %v", path, err)
+	}
 
 	// Wait for signals
 	ch := make(chan os.Signal, 1)
@@ -148,6 +244,7 @@ func main() {
 				log.Panicf("Error loading config from %s: %v", config.URL(), err)
 			}
 			bees.StopBees()
+			auth.SetTokens(config.Tokens)
 			bees.SetActions(config.Actions)
 			bees.SetChains(config.Chains)
 			bees.StartBees(config.Bees)
@@ -171,12 +268,27 @@ func main() {
 	config.Bees = bees.BeeConfigs()
 	config.Chains = bees.GetChains()
 	config.Actions = bees.GetActions()
+	config.Tokens = auth.GetTokens()
 	err = config.Save()
 	if err != nil {
 		log.Printf("Error saving config file to %s! %v", config.URL(), err)
 	}
 }
 
+// dataPath returns the directory persistent data like the event queue gets
+// stored in, next to the configuration file.
+func dataPath(config *cfg.Config, name string) string {
+	dir := filepath.Dir(cfg.DefaultPath())
+	switch config.URL().Scheme {
+	case "", "file", "crypto":
+		if config.URL().Path != "" {
+			dir = filepath.Dir(config.URL().Path)
+		}
+	}
+
+	return filepath.Join(dir, name)
+}
+
 func decryptConfig(u string) {
 	b := cfg.AESBackend{}
 
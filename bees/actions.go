@@ -22,12 +22,16 @@
 package bees
 
 import (
-	"bytes"
-	"text/template"
+	"errors"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+)
 
-	"github.com/muesli/beehive/templatehelper"
+// Policies deciding how a chain proceeds after one of its actions failed.
+const (
+	ActionFailureAbort    = "abort"
+	ActionFailureContinue = "continue"
 )
 
 // Action describes an action.
@@ -36,83 +40,205 @@ type Action struct {
 	Bee     string
 	Name    string
 	Options Placeholders
+	Retry   *RetryPolicy `json:",omitempty" yaml:",omitempty"`
+	// OnFailure is either ActionFailureAbort (the default) or
+	// ActionFailureContinue
+	OnFailure string `json:",omitempty" yaml:",omitempty"`
+	// Schedule postpones the action when it runs as part of a chain
+	Schedule *ActionSchedule `json:",omitempty" yaml:",omitempty"`
 }
 
-var (
-	actions []Action
-)
-
-// GetActions returns all configured actions.
+// GetActions returns a snapshot of all configured actions.
 func GetActions() []Action {
-	return actions
+	return defaultEngine.GetActions()
 }
 
-// GetAction returns one action with a specific ID.
+// GetActions returns a snapshot of the engine's actions.
+func (e *Engine) GetActions() []Action {
+	return e.registry.Actions()
+}
+
+// GetAction returns a copy of the action with a specific ID.
 func GetAction(id string) *Action {
-	for _, a := range actions {
-		if a.ID == id {
-			return &a
-		}
-	}
+	return defaultEngine.GetAction(id)
+}
 
-	return nil
+// GetAction returns a copy of the engine's action with a specific ID.
+func (e *Engine) GetAction(id string) *Action {
+	return e.registry.Action(id)
+}
+
+// AddAction adds an action to the configured actions.
+func AddAction(action Action) {
+	defaultEngine.AddAction(action)
+}
+
+// AddAction adds an action to the engine.
+func (e *Engine) AddAction(action Action) {
+	e.registry.AddAction(action)
+}
+
+// ValidFailurePolicy returns true if p is a known OnFailure policy.
+func ValidFailurePolicy(p string) bool {
+	return p == "" || p == ActionFailureAbort || p == ActionFailureContinue
 }
 
 // SetActions sets the currently configured actions.
 func SetActions(as []Action) {
-	actions = as
+	defaultEngine.SetActions(as)
+}
+
+// SetActions sets the engine's actions.
+func (e *Engine) SetActions(as []Action) {
+	e.registry.SetActions(as)
+}
+
+// RunAction executes an action on demand, outside of any chain. The action's
+// option templates get rendered against data. Returns the placeholders the
+// bee emitted.
+func RunAction(action Action, data map[string]interface{}) ([]Placeholder, error) {
+	return defaultEngine.RunAction(action, data)
+}
+
+// RunAction executes an action of the engine on demand.
+func (e *Engine) RunAction(action Action, data map[string]interface{}) ([]Placeholder, error) {
+	bee := e.GetBee(action.Bee)
+	if bee == nil {
+		return nil, errors.New("Bee " + action.Bee + " not registered")
+	}
+	if !(*bee).IsRunning() {
+		return nil, errors.New("Bee " + action.Bee + " is not running")
+	}
+	if e.GetActionDescriptor(&action).Name != action.Name {
+		return nil, errors.New("Bee " + action.Bee + " does not provide an action named " + action.Name)
+	}
+
+	m := make(map[string]interface{})
+	for k, v := range data {
+		m[k] = v
+	}
+	e.ctx.FillMap(m)
+
+	trace, err := e.execAction(action, m, nil)
+	return trace.Placeholders, err
 }
 
-// execAction executes an action and map its ins & outs.
-func execAction(action Action, opts map[string]interface{}) bool {
+// renderAction returns a copy of an action with all its option templates
+// rendered against opts.
+func renderAction(action Action, opts map[string]interface{}) (Action, error) {
 	a := Action{
-		Bee:  action.Bee,
-		Name: action.Name,
+		ID:        action.ID,
+		Bee:       action.Bee,
+		Name:      action.Name,
+		Retry:     action.Retry,
+		OnFailure: action.OnFailure,
 	}
 
-	for _, opt := range action.Options {
+	var err error
+	a.Options, err = renderPlaceholders(action.Bee+"_"+action.Name, action.Options, opts)
+	return a, err
+}
+
+// renderPlaceholders returns a copy of phs with all string values rendered as
+// templates against opts.
+func renderPlaceholders(name string, phs Placeholders, opts map[string]interface{}) (Placeholders, error) {
+	var r Placeholders
+	for _, opt := range phs {
 		ph := Placeholder{
 			Name: opt.Name,
 		}
 
 		switch opt.Value.(type) {
 		case string:
-			var value bytes.Buffer
-
-			tmpl, err := template.New(action.Bee + "_" + action.Name + "_" + opt.Name).Funcs(templatehelper.FuncMap).Parse(opt.Value.(string))
-			if err == nil {
-				err = tmpl.Execute(&value, opts)
-			}
+			value, err := renderTemplate(name+"_"+opt.Name, opt.Value.(string), opts)
 			if err != nil {
-				panic(err)
+				return r, err
 			}
 
 			ph.Type = "string"
-			ph.Value = value.String()
+			ph.Value = value
 
 		default:
 			ph.Type = opt.Type
 			ph.Value = opt.Value
 		}
-		a.Options = append(a.Options, ph)
+		r = append(r, ph)
+	}
+
+	return r, nil
+}
+
+// execAction executes an action and map its ins & outs. The returned trace
+// contains the rendered options and the placeholders the bee emitted. w is
+// the worker executing the action, if any.
+func (e *Engine) execAction(action Action, opts map[string]interface{}, w *worker) (trace ActionTrace, err error) {
+	trace = ActionTrace{
+		ID:        action.ID,
+		Bee:       action.Bee,
+		Name:      action.Name,
+		Timestamp: time.Now(),
+	}
+	defer func() {
+		trace.Duration = time.Since(trace.Timestamp)
+		if err != nil {
+			trace.Error = err.Error()
+		}
+	}()
+
+	a, err := renderAction(action, opts)
+	if err != nil {
+		return trace, err
 	}
+	trace.Options = a.Options
 
-	bee := GetBee(a.Bee)
+	bee := e.GetBee(a.Bee)
+	if bee == nil {
+		return trace, errors.New("Bee " + a.Bee + " not registered")
+	}
 	if (*bee).IsRunning() {
 		(*bee).LogAction()
 
-		log.Debugln("\tExecuting action:", a.Bee, "/", a.Name, "-", GetActionDescriptor(&a).Description)
+		log.Debugln("\tExecuting action:", a.Bee, "/", a.Name, "-", e.GetActionDescriptor(&a).Description)
 		for _, v := range a.Options {
 			log.Debugln("\t\tOptions:", v)
 		}
 
-		(*bee).Action(a)
-	} else {
-		log.Debugln("\tNot executing action on stopped bee:", a.Bee, "/", a.Name, "-", GetActionDescriptor(&a).Description)
-		for _, v := range a.Options {
-			log.Debugln("\t\tOptions:", v)
+		trace.Placeholders, err = e.runAction(bee, a, w)
+		return trace, err
+	}
+
+	log.Debugln("\tNot executing action on stopped bee:", a.Bee, "/", a.Name, "-", e.GetActionDescriptor(&a).Description)
+	for _, v := range a.Options {
+		log.Debugln("\t\tOptions:", v)
+	}
+	trace.Skipped = true
+
+	return trace, nil
+}
+
+// actionResults holds the placeholders returned by a chain's actions, keyed
+// by the actions' IDs.
+type actionResults map[string]interface{}
+
+// mapActionResults makes the placeholders returned by an action available to
+// subsequent actions of the same chain, namespaced by the action's ID, e.g.
+// {{index .actions "<action-id>" "<placeholder>"}}. Returns an error if the
+// event already carries a placeholder named actions.
+func mapActionResults(id string, phs []Placeholder, opts map[string]interface{}) error {
+	results, ok := opts["actions"].(actionResults)
+	if !ok {
+		if _, exists := opts["actions"]; exists {
+			return errors.New("The event's placeholder 'actions' collides with the results of the chain's actions")
 		}
+		results = make(actionResults)
+		opts["actions"] = results
 	}
 
-	return true
+	m := make(map[string]interface{})
+	for _, ph := range phs {
+		m[ph.Name] = ph.Value
+	}
+	results[id] = m
+
+	return nil
 }
@@ -0,0 +1,92 @@
+package bees
+
+import (
+	"testing"
+)
+
+func TestRunAction(t *testing.T) {
+	bee := startTestBee("runtest")
+
+	phs, err := RunAction(Action{
+		Bee:     "runtest",
+		Name:    "echo",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "{{.text}} world"}},
+	}, map[string]interface{}{"text": "hello"})
+	if err != nil {
+		t.Fatalf("Unexpected error running action: %v", err)
+	}
+	if len(phs) != 1 || phs[0].Value != "hello world" {
+		t.Errorf("Unexpected placeholders: %+v", phs)
+	}
+	if len(bee.recorded()) != 1 {
+		t.Errorf("Expected the bee to receive 1 action, got %d", len(bee.recorded()))
+	}
+
+	_, err = RunAction(Action{Bee: "runtest", Name: "unknown"}, nil)
+	if err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+	_, err = RunAction(Action{Bee: "unknown", Name: "echo"}, nil)
+	if err == nil {
+		t.Error("Expected an error for an unknown bee")
+	}
+}
+
+func TestMapActionResults(t *testing.T) {
+	m := map[string]interface{}{"text": "hello"}
+
+	mapActionResults("first", []Placeholder{{Name: "text", Type: "string", Value: "hello world"}}, m)
+	mapActionResults("second", []Placeholder{
+		{Name: "id", Type: "int", Value: 42},
+		{Name: "ok", Type: "bool", Value: true},
+	}, m)
+	mapActionResults("empty", nil, m)
+
+	results, ok := m["actions"].(actionResults)
+	if !ok || len(results) != 3 {
+		t.Fatalf("Expected the results of 3 actions, got %+v", m["actions"])
+	}
+	if m["text"] != "hello" {
+		t.Errorf("Expected the event's placeholders to stay untouched, got %v", m["text"])
+	}
+	if r := results["second"].(map[string]interface{}); r["id"] != 42 || r["ok"] != true {
+		t.Errorf("Expected the placeholders of the second action, got %+v", r)
+	}
+	if r := results["empty"].(map[string]interface{}); len(r) != 0 {
+		t.Errorf("Expected no placeholders for an action without results, got %+v", r)
+	}
+
+	a, err := renderAction(Action{
+		Bee:  "runtest",
+		Name: "echo",
+		Options: Placeholders{
+			{Name: "text", Type: "string", Value: `{{index .actions "first" "text"}}!`},
+			{Name: "id", Type: "int", Value: `{{index .actions "second" "id"}}`},
+		},
+	}, m)
+	if err != nil {
+		t.Fatalf("Unexpected error rendering action: %v", err)
+	}
+	if v := a.Options.Value("text"); v != "hello world!" {
+		t.Errorf("Expected the rendered text to contain the first action's result, got %v", v)
+	}
+	if v := a.Options.Value("id"); v != "42" {
+		t.Errorf("Expected the second action's id to be rendered as text, got %#v", v)
+	}
+
+	mapActionResults("first", []Placeholder{{Name: "text", Type: "string", Value: "again"}}, m)
+	if r := results["first"].(map[string]interface{}); r["text"] != "again" {
+		t.Errorf("Expected a repeated action to replace its results, got %+v", r)
+	}
+}
+
+func TestMapActionResultsCollision(t *testing.T) {
+	m := map[string]interface{}{"actions": "from the event"}
+
+	if err := mapActionResults("first", []Placeholder{{Name: "text", Type: "string", Value: "hello"}}, m); err == nil {
+		t.Error("Expected an error for an event placeholder named actions")
+	}
+	if m["actions"] != "from the event" {
+		t.Errorf("Expected the event's placeholder to stay untouched, got %v", m["actions"])
+	}
+}
@@ -60,6 +60,10 @@ type BeeInterface interface {
 	Start()
 	// Stop the bee
 	Stop()
+	// Status returns the bee's lifecycle state
+	Status() BeeStatus
+	// SetState changes the bee's lifecycle state
+	SetState(state BeeState, err error)
 
 	LastEvent() time.Time
 	LogEvent()
@@ -78,6 +82,14 @@ type BeeInterface interface {
 	Action(action Action) []Placeholder
 }
 
+// ActionWithErrorInterface is an optional interface for bees that report
+// failed actions with an error. If a bee implements it, the engine calls
+// ActionWithError instead of Action.
+type ActionWithErrorInterface interface {
+	// Handles an action and returns an error if it failed
+	ActionWithError(action Action) ([]Placeholder, error)
+}
+
 // Bee is the base-struct to be embedded by bee implementations.
 type Bee struct {
 	config BeeConfig
@@ -85,90 +97,87 @@ type Bee struct {
 	lastEvent  time.Time
 	lastAction time.Time
 
-	Running   bool
 	SigChan   chan bool
 	waitGroup *sync.WaitGroup
+	health    *beeHealth
+	engine    *Engine
 }
 
-var (
-	bees      = make(map[string]*BeeInterface)
-	factories = make(map[string]*BeeFactoryInterface)
-)
-
 // RegisterBee gets called by Bees to register themselves.
 func RegisterBee(bee BeeInterface) {
+	defaultEngine.RegisterBee(bee)
+}
+
+// RegisterBee registers a bee with the engine.
+func (e *Engine) RegisterBee(bee BeeInterface) {
 	log.Println("Worker bee ready:", bee.Name(), "-", bee.Description())
 
-	bees[bee.Name()] = &bee
+	e.attach(bee)
+	e.registry.RegisterBee(bee)
 }
 
 // GetBee returns a bee with a specific name.
 func GetBee(identifier string) *BeeInterface {
-	bee, ok := bees[identifier]
-	if ok {
-		return bee
-	}
+	return defaultEngine.GetBee(identifier)
+}
 
-	return nil
+// GetBee returns the engine's bee with a specific name.
+func (e *Engine) GetBee(identifier string) *BeeInterface {
+	return e.registry.Bee(identifier)
 }
 
 // GetBees returns all known bees.
 func GetBees() []*BeeInterface {
-	r := []*BeeInterface{}
-	for _, bee := range bees {
-		r = append(r, bee)
-	}
-
-	return r
+	return defaultEngine.GetBees()
 }
 
-// startBee starts a bee and recovers from panics.
-func startBee(bee *BeeInterface, fatals int) {
-	if fatals >= 3 {
-		log.Println("Terminating evil bee", (*bee).Name(), "after", fatals, "failed tries!")
-		(*bee).Stop()
-		return
-	}
-
-	(*bee).WaitGroup().Add(1)
-	defer (*bee).WaitGroup().Done()
-
-	defer func(bee *BeeInterface) {
-		if e := recover(); e != nil {
-			log.Println("Fatal bee event:", e, fatals)
-			go startBee(bee, fatals+1)
-		}
-	}(bee)
-
-	(*bee).Run(eventsIn)
+// GetBees returns all bees known to the engine.
+func (e *Engine) GetBees() []*BeeInterface {
+	return e.registry.Bees()
 }
 
 // NewBeeInstance sets up a new Bee with supplied config.
 func NewBeeInstance(bee BeeConfig) *BeeInterface {
-	factory := GetFactory(bee.Class)
+	return defaultEngine.NewBeeInstance(bee)
+}
+
+// NewBeeInstance sets up a new Bee with supplied config in the engine.
+func (e *Engine) NewBeeInstance(bee BeeConfig) *BeeInterface {
+	factory := e.GetFactory(bee.Class)
 	if factory == nil {
 		panic("Unknown bee-class in config file: " + bee.Class)
 	}
 	mod := (*factory).New(bee.Name, bee.Description, bee.Options)
-	RegisterBee(mod)
+	log.Println("Worker bee ready:", mod.Name(), "-", mod.Description())
 
-	return &mod
+	e.attach(mod)
+	return e.registry.RegisterBee(mod)
 }
 
 // DeleteBee removes a Bee instance.
 func DeleteBee(bee *BeeInterface) {
+	defaultEngine.DeleteBee(bee)
+}
+
+// DeleteBee removes a Bee instance from the engine.
+func (e *Engine) DeleteBee(bee *BeeInterface) {
 	(*bee).Stop()
 
-	delete(bees, (*bee).Name())
+	e.registry.RemoveBee((*bee).Name())
 }
 
 // StartBee starts a bee.
 func StartBee(bee BeeConfig) *BeeInterface {
-	b := NewBeeInstance(bee)
+	return defaultEngine.StartBee(bee)
+}
+
+// StartBee starts a bee in the engine.
+func (e *Engine) StartBee(bee BeeConfig) *BeeInterface {
+	b := e.NewBeeInstance(bee)
 
 	(*b).Start()
 	go func(mod *BeeInterface) {
-		startBee(mod, 0)
+		e.startBee(mod)
 	}(b)
 
 	return b
@@ -176,40 +185,63 @@ func StartBee(bee BeeConfig) *BeeInterface {
 
 // StartBees starts all registered bees.
 func StartBees(beeList []BeeConfig) {
-	eventsIn = make(chan Event)
-	go handleEvents()
+	defaultEngine.StartBees(beeList)
+}
+
+// StartBees starts the engine's event loop and all bees in beeList.
+func (e *Engine) StartBees(beeList []BeeConfig) {
+	e.startWorkers()
+	e.startEventLoop()
 
 	for _, bee := range beeList {
-		StartBee(bee)
+		e.StartBee(bee)
 	}
+
+	e.redeliverEvents()
 }
 
 // StopBees stops all bees gracefully.
 func StopBees() {
-	for _, bee := range bees {
+	defaultEngine.StopBees()
+}
+
+// StopBees stops all of the engine's bees gracefully and shuts down its
+// event loop.
+func (e *Engine) StopBees() {
+	for _, bee := range e.registry.ClearBees() {
 		log.Println("Stopping bee:", (*bee).Name())
 		(*bee).Stop()
 	}
 
-	close(eventsIn)
-	bees = make(map[string]*BeeInterface)
+	e.stopEventLoop()
+	e.stopWorkers()
 }
 
 // RestartBee restarts a Bee.
 func RestartBee(bee *BeeInterface) {
+	defaultEngine.RestartBee(bee)
+}
+
+// RestartBee restarts a Bee of the engine.
+func (e *Engine) RestartBee(bee *BeeInterface) {
 	(*bee).Stop()
 
 	(*bee).SetSigChan(make(chan bool))
 	(*bee).Start()
 	go func(mod *BeeInterface) {
-		startBee(mod, 0)
+		e.startBee(mod)
 	}(bee)
 }
 
 // RestartBees stops all running bees and restarts a new set of bees.
 func RestartBees(bees []BeeConfig) {
-	StopBees()
-	StartBees(bees)
+	defaultEngine.RestartBees(bees)
+}
+
+// RestartBees stops all of the engine's bees and starts a new set of bees.
+func (e *Engine) RestartBees(bees []BeeConfig) {
+	e.StopBees()
+	e.StartBees(bees)
 }
 
 // NewBee returns a new bee and sets up sig-channel & waitGroup.
@@ -224,6 +256,7 @@ func NewBee(name, factoryName, description string, options []BeeOption) Bee {
 		config:    c,
 		SigChan:   make(chan bool),
 		waitGroup: &sync.WaitGroup{},
+		health:    &beeHealth{status: BeeStatus{State: BeeStopped, Since: time.Now()}},
 	}
 
 	return b
@@ -300,14 +333,20 @@ func (bee *Bee) Action(action Action) []Placeholder {
 	return []Placeholder{}
 }
 
-// IsRunning returns whether a Bee is currently running.
+// IsRunning returns whether a Bee is currently running, i.e. it got started
+// and neither stopped nor failed since.
 func (bee *Bee) IsRunning() bool {
-	return bee.Running
+	switch bee.Status().State {
+	case BeeStarting, BeeRunning, BeeDegraded, BeeBackoff:
+		return true
+	}
+
+	return false
 }
 
 // Start gets called when a Bee gets started.
 func (bee *Bee) Start() {
-	bee.Running = true
+	bee.SetState(BeeStarting, nil)
 }
 
 // Stop gracefully stops a Bee.
@@ -319,7 +358,7 @@ func (bee *Bee) Stop() {
 
 	close(bee.SigChan)
 	bee.waitGroup.Wait()
-	bee.Running = false
+	bee.SetState(BeeStopped, nil)
 	log.Println(bee.Name(), "stopped gracefully!")
 }
 
@@ -351,28 +390,28 @@ func (bee *Bee) Logln(args ...interface{}) {
 	}
 
 	log.Println(a...)
-	Log(bee.Name(), fmt.Sprintln(args...), LogInfo)
+	bee.owner().Log(bee.Name(), fmt.Sprintln(args...), LogInfo)
 }
 
 // Logf logs a formatted string
 func (bee *Bee) Logf(format string, args ...interface{}) {
 	s := fmt.Sprintf(format, args...)
 	log.Printf("[%s]: %s", bee.Name(), s)
-	Log(bee.Name(), s, LogInfo)
+	bee.owner().Log(bee.Name(), s, LogInfo)
 }
 
 // LogErrorf logs a formatted error string
 func (bee *Bee) LogErrorf(format string, args ...interface{}) {
 	s := fmt.Sprintf(format, args...)
 	log.Errorf("[%s]: %s", bee.Name(), s)
-	Log(bee.Name(), s, LogError)
+	bee.owner().Log(bee.Name(), s, LogError)
 }
 
 // LogDebugf logs a formatted debug string
 func (bee *Bee) LogDebugf(format string, args ...interface{}) {
 	s := fmt.Sprintf(format, args...)
 	log.Debugf("[%s]: %s", bee.Name(), s)
-	Log(bee.Name(), s, LogDebug)
+	bee.owner().Log(bee.Name(), s, LogDebug)
 }
 
 // LogFatal logs a fatal error
@@ -382,7 +421,21 @@ func (bee *Bee) LogFatal(args ...interface{}) {
 		a = append(a, v)
 	}
 	log.Panicln(a...)
-	Log(bee.Name(), fmt.Sprintln(args...), LogFatal)
+	bee.owner().Log(bee.Name(), fmt.Sprintln(args...), LogFatal)
+}
+
+// owner returns the engine a bee belongs to.
+func (bee *Bee) owner() *Engine {
+	if bee.engine == nil {
+		return defaultEngine
+	}
+
+	return bee.engine
+}
+
+// setEngine makes a bee part of an engine.
+func (bee *Bee) setEngine(e *Engine) {
+	bee.engine = e
 }
 
 // UUID generates a new unique ID.
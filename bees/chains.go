@@ -21,7 +21,13 @@
 // Package bees is Beehive's central module system.
 package bees
 
-import log "github.com/sirupsen/logrus"
+import (
+	"path"
+	"reflect"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
 
 // ChainElement is an element in a Chain
 type ChainElement struct {
@@ -29,95 +35,497 @@ type ChainElement struct {
 	Filter Filter
 }
 
+// Trigger is an additional event a chain reacts on. Bee and Name are matched
+// literally, unless Glob is set: then they are glob patterns, e.g. "*" or
+// "telegram-*". Empty fields match everything. If Hive is set, only bees of
+// that hive class match.
+//
+// Triggers only match engine-internal events, i.e. bee_state_changed events
+// and events emitted by chains, if they name them without wildcards.
+type Trigger struct {
+	Bee  string `json:",omitempty" yaml:",omitempty"`
+	Hive string `json:",omitempty" yaml:",omitempty"`
+	Name string `json:",omitempty" yaml:",omitempty"`
+	Glob bool   `json:",omitempty" yaml:",omitempty"`
+}
+
+// Branch is a group of actions guarded by its own condition. Consecutive
+// branches form if/else-if/else groups: a branch with Else set only gets
+// evaluated when none of the preceding branches of its group passed.
+type Branch struct {
+	// Condition is a filter template, e.g. {{test eq .severity "critical"}}.
+	// A branch without a condition always passes
+	Condition string `json:",omitempty" yaml:",omitempty"`
+	// Else attaches this branch to the group of the previous branch
+	Else    bool `json:",omitempty" yaml:",omitempty"`
+	Actions []string
+}
+
 // Chain is a user defined chain
 type Chain struct {
 	Name        string
 	Description string
 	Event       *Event
+	Triggers    []Trigger        `json:",omitempty" yaml:",omitempty"`
+	Transforms  []ChainTransform `json:",omitempty" yaml:",omitempty"`
 	Filters     []string
+	// Actions get executed in order. The placeholders returned by an action
+	// are available to the following ones, keyed by the action's ID, e.g.
+	// {{index .actions "<action-id>" "<placeholder>"}}
 	Actions     []string
-	Elements    []ChainElement `json:"Elements,omitempty"`
+	Branches    []Branch          `json:",omitempty" yaml:",omitempty"`
+	OnError     []string          `json:",omitempty" yaml:",omitempty"`
+	Outputs     []ChainOutput     `json:",omitempty" yaml:",omitempty"`
+	Limits      *ChainLimits      `json:",omitempty" yaml:",omitempty"`
+	Correlation *ChainCorrelation `json:",omitempty" yaml:",omitempty"`
+	Elements    []ChainElement    `json:"Elements,omitempty"`
 }
 
-var (
-	chains []Chain
-)
-
-// GetChains returns all chains
+// GetChains returns a snapshot of all chains
 func GetChains() []Chain {
-	return chains
+	return defaultEngine.GetChains()
 }
 
-// GetChain returns a chain with a specific id
+// GetChains returns a snapshot of the engine's chains.
+func (e *Engine) GetChains() []Chain {
+	return e.registry.Chains()
+}
+
+// GetChain returns a copy of the chain with a specific id
 func GetChain(id string) *Chain {
-	for _, c := range chains {
-		if c.Name == id {
-			return &c
-		}
-	}
+	return defaultEngine.GetChain(id)
+}
 
-	return nil
+// GetChain returns a copy of the engine's chain with a specific name.
+func (e *Engine) GetChain(id string) *Chain {
+	return e.registry.Chain(id)
 }
 
 // SetChains sets the currently configured chains
 func SetChains(cs []Chain) {
-	newcs := []Chain{}
-	// migrate old chain style
-	for _, c := range cs {
-		for _, el := range c.Elements {
-			if el.Action.Name != "" {
-				el.Action.ID = UUID()
-				c.Actions = append(c.Actions, el.Action.ID)
-				actions = append(actions, el.Action)
-			}
-			if el.Filter.Name != "" {
-				//FIXME: migrate old style filters
-				c.Filters = append(c.Filters, el.Filter.Options.Value.(string))
-			}
+	defaultEngine.SetChains(cs)
+}
+
+// SetChains sets the engine's chains.
+func (e *Engine) SetChains(cs []Chain) {
+	old := e.registry.Chains()
+	e.registry.SetChains(cs)
+
+	for _, c := range old {
+		if n := e.registry.Chain(c.Name); n == nil || !reflect.DeepEqual(*n, c) {
+			e.dropChainState(c.Name)
 		}
-		c.Elements = []ChainElement{}
+	}
+}
+
+// AddChain adds a chain, unless a chain with the same name exists already
+func AddChain(c Chain) error {
+	return defaultEngine.AddChain(c)
+}
+
+// AddChain adds a chain to the engine.
+func (e *Engine) AddChain(c Chain) error {
+	return e.registry.AddChain(c)
+}
+
+// DeleteChain removes a chain. Returns false if there was no such chain
+func DeleteChain(id string) bool {
+	return defaultEngine.DeleteChain(id)
+}
 
-		newcs = append(newcs, c)
+// DeleteChain removes a chain from the engine.
+func (e *Engine) DeleteChain(id string) bool {
+	if !e.registry.DeleteChain(id) {
+		return false
 	}
 
-	chains = newcs
+	e.dropChainState(id)
+	return true
 }
 
-// execChains executes chains for an event we received
-func execChains(event *Event) {
-	for _, c := range chains {
-		if c.Event.Name != event.Name || c.Event.Bee != event.Bee {
-			continue
+// dropChainState discards the runtime state of a chain which got edited or
+// deleted, so events seen by its old definition don't affect the new one.
+func (e *Engine) dropChainState(name string) {
+	e.dropCorrelator(name)
+	e.dropLimiter(name)
+}
+
+// matchPattern reports whether s matches a pattern. An empty pattern matches
+// everything; other patterns are only treated as globs if glob is set.
+func matchPattern(pattern, s string, glob bool) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if !glob {
+		return pattern == s
+	}
+
+	ok, err := path.Match(pattern, s)
+	return err == nil && ok
+}
+
+// eventHive returns the hive class of the bee which emitted an event.
+func (e *Engine) eventHive(event *Event) string {
+	bee := e.GetBee(event.Bee)
+	if bee == nil {
+		return ""
+	}
+
+	return (*bee).Namespace()
+}
+
+// triggeredBy reports whether a chain reacts on an event emitted by a bee of
+// the given hive class, either through its Event or one of its Triggers.
+func (c Chain) triggeredBy(event *Event, hive string) bool {
+	if c.Event != nil && len(c.Event.Bee) > 0 && len(c.Event.Name) > 0 &&
+		c.Event.Bee == event.Bee && c.Event.Name == event.Name {
+		return true
+	}
+
+	for _, t := range c.Triggers {
+		if t.matches(event, hive) {
+			return true
 		}
+	}
+
+	return false
+}
 
-		m := make(map[string]interface{})
-		for _, opt := range event.Options {
-			m[opt.Name] = opt.Value
+// matches reports whether an event emitted by a bee of the given hive class
+// matches the trigger.
+func (t *Trigger) matches(event *Event, hive string) bool {
+	if !matchPattern(t.Bee, event.Bee, t.Glob) || !matchPattern(t.Name, event.Name, t.Glob) ||
+		(len(t.Hive) != 0 && t.Hive != hive) {
+		return false
+	}
+
+	switch {
+	case IsChainEvent(event):
+		return t.Bee == event.Bee
+	case event.Name == BeeStateChangedEvent:
+		return t.Name == event.Name
+	}
+
+	return true
+}
+
+// execChains executes chains for an event we received. The placeholders
+// returned by each action get passed on to the following actions of a chain.
+// ack, if set, tracks the executions until they are finished. w is the
+// worker processing the event, if any.
+func (e *Engine) execChains(event *Event, ack *pendingAck, w *worker) {
+	hive := e.eventHive(event)
+	for _, c := range e.GetChains() {
+		if !c.triggeredBy(event, hive) {
+			continue
 		}
-		ctx.FillMap(m)
 
-		failed := false
 		log.Debugln("Executing chain:", c.Name, "-", c.Description)
-		for _, el := range c.Filters {
-			if execFilter(el, m) {
-				log.Debugln("\t\tPassed filter!")
-			} else {
-				log.Debugln("\t\tDid not pass filter!")
-				failed = true
+		m, x, status := e.evalChain(c, event, true)
+		x.ack = ack
+		ack.add()
+		for _, f := range x.Filters {
+			if len(f.Transform) > 0 {
+				continue
+			}
+			result := "failed"
+			if len(f.Error) > 0 {
+				result = "error"
+			} else if f.Passed {
+				result = "passed"
+			}
+			filterEvaluationsTotal.WithLabelValues(c.Name, result).Inc()
+		}
+		if len(status) > 0 {
+			x.finish(status)
+			continue
+		}
+
+		if c.Correlation != nil {
+			e.correlate(c, event, hive, m, x, w)
+			continue
+		}
+		e.fireChainActions(c, m, x, w)
+	}
+}
+
+// fireChainActions executes a chain's actions, unless the chain's limits
+// suppress it.
+func (e *Engine) fireChainActions(c Chain, m map[string]interface{}, x *Execution, w *worker) {
+	e.fireChain(c, m, func(c Chain, m map[string]interface{}, w *worker) {
+		e.execChainActions(c, m, x, w)
+	}, func() {
+		x.finish(ExecutionSuppressed)
+	}, w)
+}
+
+// evalChain prepares the template data for an event, derives the chain's
+// transformed fields and runs the chain's filters on it. Unless stopOnFailure
+// is set, all filters get evaluated, even after one of them failed. The
+// event's origin is available to templates as {{.match.bee}}, {{.match.event}}
+// and {{.match.hive}}.
+// Returns ExecutionFailed if a transform failed, ExecutionFiltered if a
+// filter didn't pass and an empty status otherwise.
+func (e *Engine) evalChain(c Chain, event *Event, stopOnFailure bool) (map[string]interface{}, *Execution, string) {
+	m := make(map[string]interface{})
+	for _, opt := range event.Options {
+		m[opt.Name] = opt.Value
+	}
+	e.ctx.FillMap(m)
+	m["match"] = map[string]interface{}{
+		"bee":   event.Bee,
+		"event": event.Name,
+		"hive":  e.eventHive(event),
+	}
+
+	x := e.newExecution(c, *event)
+	if !execTransforms(c, m, x) {
+		return m, x, ExecutionFailed
+	}
+
+	status := ""
+	for _, el := range c.Filters {
+		trace := execFilter(el, m)
+		x.Filters = append(x.Filters, trace)
+		if trace.Passed {
+			log.Debugln("\t\tPassed filter!")
+		} else {
+			log.Debugln("\t\tDid not pass filter!")
+			status = ExecutionFiltered
+			if stopOnFailure {
 				break
 			}
 		}
-		if failed {
+	}
+
+	return m, x, status
+}
+
+// SimulateChain runs a chain against an event without executing any of its
+// actions. The returned trace contains the filter results and the rendered
+// options each action would receive.
+func SimulateChain(c Chain, event Event) Execution {
+	return defaultEngine.SimulateChain(c, event)
+}
+
+// SimulateChain dry-runs a chain against the engine's bees and actions.
+func (e *Engine) SimulateChain(c Chain, event Event) Execution {
+	if c.Event != nil {
+		if len(event.Bee) == 0 {
+			event.Bee = c.Event.Bee
+		}
+		if len(event.Name) == 0 {
+			event.Name = c.Event.Name
+		}
+	}
+
+	m, x, status := e.evalChain(c, &event, false)
+	if len(status) > 0 {
+		x.complete(status)
+		return *x
+	}
+
+	for _, el := range c.Actions {
+		e.simulateAction(el, m, x)
+	}
+	execBranches(c, m, x, func(id string) bool {
+		e.simulateAction(id, m, x)
+		return true
+	})
+
+	x.complete(ExecutionSimulated)
+	return *x
+}
+
+// simulateAction records the rendered options of an action in an execution
+// trace, without executing it.
+func (e *Engine) simulateAction(id string, m map[string]interface{}, x *Execution) {
+	action := e.GetAction(id)
+	if action == nil {
+		x.Actions = append(x.Actions, ActionTrace{
+			ID:    id,
+			Error: "Unknown action referenced",
+		})
+		return
+	}
+
+	trace := ActionTrace{
+		ID:        action.ID,
+		Bee:       action.Bee,
+		Name:      action.Name,
+		Skipped:   true,
+		Timestamp: time.Now(),
+	}
+	a, err := renderAction(*action, m)
+	trace.Options = a.Options
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	trace.Duration = time.Since(trace.Timestamp)
+
+	x.Actions = append(x.Actions, trace)
+}
+
+// execBranches evaluates the conditions of a chain's branches and calls run
+// for every action of the branches that passed. Conditions are evaluated
+// lazily, so they can refer to the results of preceding actions. Stops as
+// soon as run returns false.
+func execBranches(c Chain, m map[string]interface{}, x *Execution, run func(id string) bool) bool {
+	matched := false
+	for i, b := range c.Branches {
+		if !b.Else {
+			matched = false
+		} else if matched {
 			continue
 		}
 
-		for _, el := range c.Actions {
-			action := GetAction(el)
-			if action == nil {
-				log.Println("\t\tERROR: Unknown action referenced!")
+		if len(b.Condition) > 0 {
+			trace := execFilter(b.Condition, m)
+			trace.Branch = i + 1
+			x.Filters = append(x.Filters, trace)
+			if !trace.Passed {
+				log.Debugln("\t\tSkipping branch", i+1)
 				continue
 			}
-			execAction(*action, m)
+		}
+		matched = true
+
+		for _, el := range b.Actions {
+			if !run(el) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// execChainActions executes all actions of a chain, followed by the actions
+// of its matching branches, and records them in the chain's execution trace.
+// Once all actions succeeded, the chain's outputs get emitted.
+// When an action fails, the chain's OnError actions get executed and the
+// failed action's OnFailure policy decides whether the remaining actions
+// still run.
+func (e *Engine) execChainActions(c Chain, m map[string]interface{}, x *Execution, w *worker) {
+	status := ExecutionFailed
+	defer func() {
+		x.finish(status)
+	}()
+
+	run := func(id string) bool {
+		return e.execChainAction(c, id, m, x, w)
+	}
+
+	for _, el := range c.Actions {
+		if !run(el) {
+			return
+		}
+	}
+	if !execBranches(c, m, x, run) {
+		return
+	}
+	e.emitChainOutputs(c, m, x)
+
+	status = ExecutionCompleted
+}
+
+// execChainAction executes, or schedules, a single action of a chain. Returns
+// false if the chain should be aborted.
+func (e *Engine) execChainAction(c Chain, id string, m map[string]interface{}, x *Execution, w *worker) bool {
+	action := e.GetAction(id)
+	if action == nil {
+		log.Println("\t\tERROR: Unknown action referenced!")
+		return true
+	}
+
+	var trace ActionTrace
+	var err error
+	if action.Schedule != nil {
+		trace, err = e.scheduleChainAction(c, *action, m, x)
+	} else {
+		trace, err = e.execAction(*action, m, w)
+	}
+	x.Actions = append(x.Actions, trace)
+	if err != nil {
+		e.execErrorActions(c, trace, m, x, w)
+		if action.OnFailure != ActionFailureContinue {
+			log.Errorln("\t\tERROR: Action failed, aborting chain:", err)
+			return false
+		}
+
+		log.Errorln("\t\tERROR: Action failed, continuing chain:", err)
+		return true
+	}
+	if err := mapActionResults(action.ID, trace.Placeholders, m); err != nil {
+		log.Errorln("\t\tERROR: Can't pass on the action's results, aborting chain:", err)
+		x.Actions[len(x.Actions)-1].Error = err.Error()
+		return false
+	}
+
+	return true
+}
+
+// scheduleChainAction schedules an action of a chain for later execution.
+// The returned trace contains the rendered options and the time the action
+// is due.
+func (e *Engine) scheduleChainAction(c Chain, action Action, m map[string]interface{}, x *Execution) (ActionTrace, error) {
+	trace := ActionTrace{
+		ID:        action.ID,
+		Bee:       action.Bee,
+		Name:      action.Name,
+		Timestamp: time.Now(),
+	}
+
+	sa, err := e.scheduleAction(c, action, m, x.ID)
+	trace.Options = sa.Action.Options
+	trace.Duration = time.Since(trace.Timestamp)
+	if err != nil {
+		trace.Error = err.Error()
+		return trace, err
+	}
+
+	log.Debugln("\t\tScheduled action for", sa.Due)
+	trace.Scheduled = sa.Due
+	return trace, nil
+}
+
+// execErrorActions executes a chain's OnError actions after one of its
+// actions failed. The failed action is available to their templates as
+// {{.error.action}}, {{.error.bee}}, {{.error.id}}, {{.error.message}} and
+// {{.error.options.<name>}}.
+func (e *Engine) execErrorActions(c Chain, failed ActionTrace, m map[string]interface{}, x *Execution, w *worker) {
+	if len(c.OnError) == 0 {
+		return
+	}
+
+	opts := make(map[string]interface{})
+	for _, opt := range failed.Options {
+		opts[opt.Name] = opt.Value
+	}
+
+	em := make(map[string]interface{})
+	for k, v := range m {
+		em[k] = v
+	}
+	em["error"] = map[string]interface{}{
+		"action":  failed.Name,
+		"bee":     failed.Bee,
+		"id":      failed.ID,
+		"message": failed.Error,
+		"options": opts,
+	}
+
+	for _, el := range c.OnError {
+		action := e.GetAction(el)
+		if action == nil {
+			log.Println("\t\tERROR: Unknown error action referenced!")
+			continue
+		}
+		trace, err := e.execAction(*action, em, w)
+		trace.OnError = true
+		x.Actions = append(x.Actions, trace)
+		if err != nil {
+			log.Errorln("\t\tERROR: Error action failed:", err)
 		}
 	}
 }
@@ -0,0 +1,473 @@
+package bees
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/muesli/beehive/filters/template"
+)
+
+type testBeeFactory struct {
+	BeeFactory
+}
+
+func (factory *testBeeFactory) ID() string          { return "testbee" }
+func (factory *testBeeFactory) Name() string        { return "Test" }
+func (factory *testBeeFactory) Description() string { return "A bee for testing" }
+
+func (factory *testBeeFactory) New(name, description string, options BeeOptions) BeeInterface {
+	bee := testBee{Bee: NewBee(name, factory.ID(), description, options)}
+	return &bee
+}
+
+func (factory *testBeeFactory) Events() []EventDescriptor {
+	return []EventDescriptor{
+		{
+			Namespace: factory.ID(),
+			Name:      "message",
+			Options: []PlaceholderDescriptor{
+				{Name: "text", Type: "string", Mandatory: true},
+				{Name: "count", Type: "int"},
+				{Name: "secret", Type: "password"},
+			},
+		},
+	}
+}
+
+func (factory *testBeeFactory) Actions() []ActionDescriptor {
+	return []ActionDescriptor{
+		{
+			Namespace: factory.ID(),
+			Name:      "echo",
+			Options: []PlaceholderDescriptor{
+				{Name: "text", Type: "string"},
+				{Name: "secret", Type: "password"},
+			},
+		},
+		{
+			Namespace: factory.ID(),
+			Name:      "fail",
+			Options: []PlaceholderDescriptor{
+				{Name: "text", Type: "string"},
+			},
+		},
+	}
+}
+
+type testBee struct {
+	Bee
+
+	mutex   sync.Mutex
+	actions []Action
+}
+
+func (bee *testBee) ReloadOptions(options BeeOptions) {
+}
+
+// Action echoes its options back as placeholders. The "fail" action panics.
+func (bee *testBee) Action(action Action) []Placeholder {
+	bee.mutex.Lock()
+	bee.actions = append(bee.actions, action)
+	bee.mutex.Unlock()
+
+	if action.Name == "fail" {
+		panic("failed on purpose")
+	}
+	return action.Options
+}
+
+// recorded returns the actions the bee received so far.
+func (bee *testBee) recorded() []Action {
+	bee.mutex.Lock()
+	defer bee.mutex.Unlock()
+
+	return append([]Action{}, bee.actions...)
+}
+
+// reset forgets all actions the bee received.
+func (bee *testBee) reset() {
+	bee.mutex.Lock()
+	defer bee.mutex.Unlock()
+
+	bee.actions = nil
+}
+
+// waitForActions waits until the bee received n actions, or a second passed.
+func (bee *testBee) waitForActions(n int) []Action {
+	deadline := time.Now().Add(time.Second)
+	for {
+		r := bee.recorded()
+		if len(r) >= n || time.Now().After(deadline) {
+			return r
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func init() {
+	RegisterFactory(&testBeeFactory{})
+}
+
+// startTestBee registers a running test bee.
+func startTestBee(name string) *testBee {
+	return startEngineTestBee(defaultEngine, name)
+}
+
+// startEngineTestBee registers a running test bee with an engine.
+func startEngineTestBee(e *Engine, name string) *testBee {
+	b := e.NewBeeInstance(BeeConfig{Name: name, Class: "testbee"})
+	(*b).Start()
+	return (*b).(*testBee)
+}
+
+func TestExecChains(t *testing.T) {
+	bee := startTestBee("chaintest")
+	defer DeleteBee(GetBee("chaintest"))
+
+	SetActions([]Action{
+		{
+			ID:      "first",
+			Bee:     "chaintest",
+			Name:    "echo",
+			Options: Placeholders{{Name: "text", Type: "string", Value: "{{.text}} world"}},
+		},
+		{
+			ID:      "second",
+			Bee:     "chaintest",
+			Name:    "echo",
+			Options: Placeholders{{Name: "text", Type: "string", Value: `{{index .actions "first" "text"}}!`}},
+		},
+	})
+	SetChains([]Chain{
+		{
+			Name:    "passing",
+			Event:   &Event{Bee: "chaintest", Name: "message"},
+			Filters: []string{`{{test eq .text "hello"}}`},
+			Actions: []string{"first", "second"},
+		},
+		{
+			Name:    "filtered",
+			Event:   &Event{Bee: "chaintest", Name: "message"},
+			Filters: []string{`{{test eq .text "bye"}}`},
+			Actions: []string{"first"},
+		},
+	})
+	defer SetChains([]Chain{})
+
+	defaultEngine.execChains(&Event{
+		Bee:     "chaintest",
+		Name:    "message",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "hello"}},
+	}, nil, nil)
+
+	if len(bee.recorded()) != 2 {
+		t.Fatalf("Expected 2 actions to be executed, got %d", len(bee.recorded()))
+	}
+	if v := bee.recorded()[1].Options.Value("text"); v != "hello world!" {
+		t.Errorf("Expected the second action to receive the first action's output, got %v", v)
+	}
+
+	xs := GetExecutions(ExecutionQuery{Chain: "passing"})
+	if len(xs) == 0 || xs[0].Status != ExecutionCompleted || len(xs[0].Actions) != 2 {
+		t.Fatalf("Expected a completed execution trace, got %+v", xs)
+	}
+	if !xs[0].Filters[0].Passed || xs[0].Filters[0].Rendered != "true" {
+		t.Errorf("Unexpected filter trace: %+v", xs[0].Filters[0])
+	}
+
+	xs = GetExecutions(ExecutionQuery{Chain: "filtered"})
+	if len(xs) == 0 || xs[0].Status != ExecutionFiltered || len(xs[0].Actions) != 0 {
+		t.Errorf("Expected a filtered execution trace, got %+v", xs)
+	}
+}
+
+func TestExecutionRedactsPasswords(t *testing.T) {
+	e := NewEngine()
+	bee := startEngineTestBee(e, "secrettest")
+	defer e.DeleteBee(e.GetBee("secrettest"))
+
+	e.SetActions([]Action{{
+		ID:      "login",
+		Bee:     "secrettest",
+		Name:    "echo",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "{{.text}}"}, {Name: "secret", Type: "string", Value: "{{.secret}}"}},
+	}})
+	e.SetChains([]Chain{{
+		Name:    "secret",
+		Event:   &Event{Bee: "secrettest", Name: "message"},
+		Actions: []string{"login"},
+	}})
+	defer e.SetChains([]Chain{})
+
+	event := &Event{
+		Bee:  "secrettest",
+		Name: "message",
+		Options: Placeholders{
+			{Name: "text", Type: "string", Value: "hello"},
+			{Name: "secret", Type: "string", Value: "hunter2"},
+			{Name: "token", Type: "password", Value: "t0ken"},
+		},
+	}
+	e.execChains(event, nil, nil)
+
+	if v := bee.recorded()[0].Options.Value("secret"); v != "hunter2" {
+		t.Errorf("Expected the action to receive the password, got %v", v)
+	}
+	if v := event.Options.Value("secret"); v != "hunter2" {
+		t.Errorf("Expected the event itself to stay untouched, got %v", v)
+	}
+
+	x := e.GetExecutions(ExecutionQuery{Chain: "secret"})[0]
+	for _, phs := range []Placeholders{x.Event.Options, x.Actions[0].Options, x.Actions[0].Placeholders} {
+		if v := phs.Value("secret"); v != redactedValue {
+			t.Errorf("Expected the password to be redacted, got %v", v)
+		}
+		if v := phs.Value("text"); v != "hello" {
+			t.Errorf("Expected other placeholders to be recorded verbatim, got %v", v)
+		}
+	}
+	if v := x.Event.Options.Value("token"); v != redactedValue {
+		t.Errorf("Expected a password-typed placeholder to be redacted, got %v", v)
+	}
+}
+
+func TestSimulateChain(t *testing.T) {
+	bee := startTestBee("simulationtest")
+	defer DeleteBee(GetBee("simulationtest"))
+
+	SetActions([]Action{
+		{
+			ID:      "notify",
+			Bee:     "simulationtest",
+			Name:    "echo",
+			Options: Placeholders{{Name: "text", Type: "string", Value: "{{.host}} is down"}},
+		},
+	})
+	c := Chain{
+		Name:    "simulated",
+		Event:   &Event{Bee: "simulationtest", Name: "alert"},
+		Filters: []string{`{{test eq .state "down"}}`, `{{test eq .host "db"}}`},
+		Actions: []string{"notify"},
+	}
+
+	x := SimulateChain(c, Event{Options: Placeholders{
+		{Name: "host", Type: "string", Value: "web"},
+		{Name: "state", Type: "string", Value: "down"},
+	}})
+	if x.Status != ExecutionFiltered || len(x.Filters) != 2 || !x.Filters[0].Passed || x.Filters[1].Passed {
+		t.Errorf("Expected all filters to be evaluated, got %+v", x.Filters)
+	}
+
+	x = SimulateChain(c, Event{Options: Placeholders{
+		{Name: "host", Type: "string", Value: "db"},
+		{Name: "state", Type: "string", Value: "down"},
+	}})
+	if x.Status != ExecutionSimulated || len(x.Actions) != 1 {
+		t.Fatalf("Expected a simulated execution, got %+v", x)
+	}
+	if v := x.Actions[0].Options.Value("text"); v != "db is down" {
+		t.Errorf("Expected rendered action options, got %v", v)
+	}
+	if len(bee.recorded()) != 0 {
+		t.Error("Simulating a chain must not execute its actions")
+	}
+}
+
+func TestChainErrorActions(t *testing.T) {
+	bee := startTestBee("errortest")
+	defer DeleteBee(GetBee("errortest"))
+
+	SetActions([]Action{
+		{
+			ID:      "slack",
+			Bee:     "errortest",
+			Name:    "fail",
+			Options: Placeholders{{Name: "text", Type: "string", Value: "{{.text}}"}},
+		},
+		{
+			ID:        "tolerated",
+			Bee:       "errortest",
+			Name:      "fail",
+			OnFailure: ActionFailureContinue,
+		},
+		{
+			ID:      "email",
+			Bee:     "errortest",
+			Name:    "echo",
+			Options: Placeholders{{Name: "text", Type: "string", Value: "{{.error.action}} failed ({{.error.message}}): {{.error.options.text}}"}},
+		},
+		{
+			ID:   "after",
+			Bee:  "errortest",
+			Name: "echo",
+		},
+	})
+	SetChains([]Chain{
+		{
+			Name:    "aborting",
+			Event:   &Event{Bee: "errortest", Name: "message"},
+			Actions: []string{"slack", "after"},
+			OnError: []string{"email"},
+		},
+		{
+			Name:    "continuing",
+			Event:   &Event{Bee: "errortest", Name: "message"},
+			Actions: []string{"tolerated", "after"},
+		},
+	})
+	defer SetChains([]Chain{})
+
+	defaultEngine.execChains(&Event{
+		Bee:     "errortest",
+		Name:    "message",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "hello"}},
+	}, nil, nil)
+
+	// slack, email, tolerated, after
+	if len(bee.recorded()) != 4 {
+		t.Fatalf("Expected 4 actions to be executed, got %d", len(bee.recorded()))
+	}
+	if v := bee.recorded()[1].Options.Value("text"); v != "fail failed (failed on purpose): hello" {
+		t.Errorf("Expected the error action to receive the failed action, got %v", v)
+	}
+
+	xs := GetExecutions(ExecutionQuery{Chain: "aborting"})
+	if len(xs) == 0 || xs[0].Status != ExecutionFailed || len(xs[0].Actions) != 2 || !xs[0].Actions[1].OnError {
+		t.Errorf("Expected a failed execution trace including the error action, got %+v", xs)
+	}
+	xs = GetExecutions(ExecutionQuery{Chain: "continuing"})
+	if len(xs) == 0 || xs[0].Status != ExecutionCompleted || len(xs[0].Actions) != 2 {
+		t.Errorf("Expected the chain to continue after a tolerated failure, got %+v", xs)
+	}
+}
+
+func TestChainBranches(t *testing.T) {
+	bee := startTestBee("branchtest")
+	defer DeleteBee(GetBee("branchtest"))
+
+	var as []Action
+	for _, id := range []string{"page", "slack", "log", "always"} {
+		as = append(as, Action{
+			ID:      id,
+			Bee:     "branchtest",
+			Name:    "echo",
+			Options: Placeholders{{Name: "text", Type: "string", Value: id}},
+		})
+	}
+	SetActions(as)
+	SetChains([]Chain{{
+		Name:  "routing",
+		Event: &Event{Bee: "branchtest", Name: "message"},
+		Branches: []Branch{
+			{Condition: `{{test eq .text "critical"}}`, Actions: []string{"page"}},
+			{Condition: `{{test eq .text "warning"}}`, Else: true, Actions: []string{"slack"}},
+			{Else: true, Actions: []string{"log"}},
+			{Actions: []string{"always"}},
+		},
+	}})
+	defer SetChains([]Chain{})
+
+	cases := []struct {
+		severity string
+		expected []string
+	}{
+		{"critical", []string{"page", "always"}},
+		{"warning", []string{"slack", "always"}},
+		{"info", []string{"log", "always"}},
+	}
+	for _, c := range cases {
+		bee.reset()
+		defaultEngine.execChains(&Event{
+			Bee:     "branchtest",
+			Name:    "message",
+			Options: Placeholders{{Name: "text", Type: "string", Value: c.severity}},
+		}, nil, nil)
+
+		var executed []string
+		for _, a := range bee.recorded() {
+			executed = append(executed, a.Options.Value("text").(string))
+		}
+		if fmt.Sprint(executed) != fmt.Sprint(c.expected) {
+			t.Errorf("Expected %v to be executed for %s, got %v", c.expected, c.severity, executed)
+		}
+	}
+
+	x := SimulateChain(*GetChain("routing"), Event{Options: Placeholders{{Name: "text", Type: "string", Value: "warning"}}})
+	if len(x.Actions) != 2 || x.Actions[0].ID != "slack" || len(x.Filters) != 2 || x.Filters[1].Branch != 2 {
+		t.Errorf("Expected the simulation to follow the branches, got %+v", x)
+	}
+}
+
+func TestChainTriggers(t *testing.T) {
+	bee := startTestBee("chat-irc")
+	defer DeleteBee(GetBee("chat-irc"))
+	other := startTestBee("chat-slack")
+	defer DeleteBee(GetBee("chat-slack"))
+
+	SetActions([]Action{{
+		ID:      "archive",
+		Bee:     "chat-irc",
+		Name:    "echo",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "{{.match.bee}}/{{.match.event}}/{{.match.hive}}: {{.text}}"}},
+	}})
+	cases := []Chain{
+		{Triggers: []Trigger{{Bee: "chat-*", Name: "*", Glob: true}}},
+		{Event: &Event{}, Triggers: []Trigger{{Bee: "chat-irc", Name: "message"}, {Bee: "chat-slack", Name: "message"}}},
+		{Triggers: []Trigger{{Hive: "testbee"}}},
+	}
+	defer SetChains([]Chain{})
+
+	for i, c := range cases {
+		c.Name = "archive"
+		c.Actions = []string{"archive"}
+		SetChains([]Chain{c, {Name: "unrelated", Event: &Event{Bee: "chat-irc", Name: "other"}, Actions: []string{"archive"}}})
+
+		bee.reset()
+		for _, b := range []string{"chat-irc", "chat-slack"} {
+			defaultEngine.execChains(&Event{
+				Bee:     b,
+				Name:    "message",
+				Options: Placeholders{{Name: "text", Type: "string", Value: "hi"}},
+			}, nil, nil)
+		}
+
+		if len(bee.recorded()) != 2 {
+			t.Errorf("Expected chain %d to be triggered by both bees, got %d actions", i, len(bee.recorded()))
+			continue
+		}
+		if v := bee.recorded()[1].Options.Value("text"); v != "chat-slack/message/testbee: hi" {
+			t.Errorf("Expected the matching bee and event in the template data, got %v", v)
+		}
+	}
+	if len(other.recorded()) != 0 {
+		t.Errorf("Unexpected actions on %s: %+v", other.Name(), other.recorded())
+	}
+}
+
+func TestTriggerPatterns(t *testing.T) {
+	stateChange := &Event{Bee: "chat-irc", Name: BeeStateChangedEvent}
+	output := &Event{Bee: ChainEventPrefix + "alerts", Name: "escalate"}
+	cases := []struct {
+		trigger Trigger
+		event   *Event
+		match   bool
+	}{
+		{Trigger{Bee: "chat-*"}, &Event{Bee: "chat-*", Name: "message"}, true},
+		{Trigger{Bee: "chat-*"}, &Event{Bee: "chat-irc", Name: "message"}, false},
+		{Trigger{Bee: "chat-*", Glob: true}, &Event{Bee: "chat-irc", Name: "message"}, true},
+		{Trigger{Bee: "chat-*", Glob: true}, stateChange, false},
+		{Trigger{Hive: "testbee"}, stateChange, false},
+		{Trigger{Bee: "chat-irc"}, stateChange, false},
+		{Trigger{Bee: "chat-*", Name: BeeStateChangedEvent, Glob: true}, stateChange, true},
+		{Trigger{Name: "*", Glob: true}, output, false},
+		{Trigger{Bee: "chain:*", Glob: true}, output, false},
+		{Trigger{Bee: ChainEventPrefix + "alerts"}, output, true},
+	}
+	for _, c := range cases {
+		if c.trigger.matches(c.event, "testbee") != c.match {
+			t.Errorf("Expected trigger %+v matching %+v to be %v", c.trigger, *c.event, c.match)
+		}
+	}
+}
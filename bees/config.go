@@ -57,8 +57,13 @@ func NewBeeConfig(name, class, description string, options BeeOptions) (BeeConfi
 
 // BeeConfigs returns configs for all Bees.
 func BeeConfigs() []BeeConfig {
+	return defaultEngine.BeeConfigs()
+}
+
+// BeeConfigs returns configs for all of the engine's Bees.
+func (e *Engine) BeeConfigs() []BeeConfig {
 	bs := []BeeConfig{}
-	for _, b := range bees {
+	for _, b := range e.GetBees() {
 		bs = append(bs, (*b).Config())
 	}
 
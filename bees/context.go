@@ -21,10 +21,6 @@
 // Package bees is Beehive's central module system.
 package bees
 
-var (
-	ctx = NewContext()
-)
-
 type Context struct {
 	state map[*Bee]map[string]interface{}
 }
@@ -55,9 +51,9 @@ func (c *Context) FillMap(m map[string]interface{}) {
 }
 
 func (bee *Bee) ContextSet(key string, value interface{}) {
-	ctx.Set(bee, key, value)
+	bee.owner().ctx.Set(bee, key, value)
 }
 
 func (bee *Bee) ContextValue(key string) interface{} {
-	return ctx.Value(bee, key)
+	return bee.owner().ctx.Value(bee, key)
 }
@@ -0,0 +1,324 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Supported types of correlations
+const (
+	// CorrelationCount fires after Count matching events within Window
+	CorrelationCount = "count"
+	// CorrelationSequence fires when a First event is followed by a Then
+	// event within Window
+	CorrelationSequence = "sequence"
+	// CorrelationAbsence fires when a First event is not followed by a Then
+	// event within Window
+	CorrelationAbsence = "absence"
+)
+
+// ChainCorrelation makes a chain fire on a pattern across several events,
+// instead of on every single event. Only events which passed the chain's
+// filters get correlated. The correlated events are available to the
+// action templates as {{.events}}, oldest first.
+type ChainCorrelation struct {
+	// Type is one of "count", "sequence" or "absence"
+	Type string
+	// Count is the amount of events a "count" correlation requires
+	Count int `json:",omitempty" yaml:",omitempty"`
+	// Window is the time frame the events must occur in, e.g. "10m"
+	Window string
+	// First and Then select the two events of "sequence" and "absence"
+	// correlations. The chain itself must be triggered by both of them
+	First *Trigger `json:",omitempty" yaml:",omitempty"`
+	Then  *Trigger `json:",omitempty" yaml:",omitempty"`
+	// Key is an optional template, e.g. "{{.host}}". Events only get
+	// correlated with events of the same rendered key
+	Key string `json:",omitempty" yaml:",omitempty"`
+}
+
+type correlatedEvent struct {
+	event     Event
+	data      map[string]interface{}
+	filters   []FilterTrace
+	timestamp time.Time
+}
+
+type chainCorrelator struct {
+	sync.Mutex
+
+	engine *Engine
+	events map[string][]correlatedEvent
+	timers map[string]*time.Timer
+	swept  time.Time
+}
+
+// Validate checks the correlation for invalid values.
+func (cc *ChainCorrelation) Validate() error {
+	if cc == nil {
+		return nil
+	}
+
+	switch cc.Type {
+	case CorrelationCount:
+		if cc.Count < 1 {
+			return errors.New("Count correlations require a positive count")
+		}
+	case CorrelationSequence, CorrelationAbsence:
+		if cc.First == nil || cc.Then == nil {
+			return errors.New("Sequence and absence correlations require a first and a then event")
+		}
+	default:
+		return errors.New("Unknown correlation type: " + cc.Type)
+	}
+
+	d, err := time.ParseDuration(cc.Window)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return errors.New("Correlation window must be positive")
+	}
+
+	return nil
+}
+
+func (e *Engine) getCorrelator(name string) *chainCorrelator {
+	e.correlatorMutex.Lock()
+	defer e.correlatorMutex.Unlock()
+
+	cr, ok := e.correlators[name]
+	if !ok {
+		cr = &chainCorrelator{
+			engine: e,
+			events: make(map[string][]correlatedEvent),
+			timers: make(map[string]*time.Timer),
+		}
+		e.correlators[name] = cr
+	}
+
+	return cr
+}
+
+// dropCorrelator discards all pending correlations of a chain.
+func (e *Engine) dropCorrelator(name string) {
+	e.correlatorMutex.Lock()
+	cr, ok := e.correlators[name]
+	delete(e.correlators, name)
+	e.correlatorMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	cr.Lock()
+	defer cr.Unlock()
+	for _, t := range cr.timers {
+		t.Stop()
+	}
+	cr.events = make(map[string][]correlatedEvent)
+	cr.timers = make(map[string]*time.Timer)
+}
+
+// correlate records an event for a chain's correlation and fires the chain
+// once the correlation is complete. Events which don't complete the
+// correlation finish their execution trace as pending.
+func (e *Engine) correlate(c Chain, event *Event, hive string, m map[string]interface{}, x *Execution, w *worker) {
+	cc := c.Correlation
+
+	key := ""
+	if len(cc.Key) > 0 {
+		var err error
+		key, err = renderTemplate(c.Name+"_correlationkey", cc.Key, m)
+		if err != nil {
+			log.Errorln("\t\tERROR: Failed to render correlation key:", err)
+		}
+	}
+
+	data := make(map[string]interface{})
+	for _, opt := range event.Options {
+		data[opt.Name] = opt.Value
+	}
+	data["match"] = m["match"]
+	for _, t := range c.Transforms {
+		data[t.Name] = m[t.Name]
+	}
+	ce := correlatedEvent{
+		event:     *event,
+		data:      data,
+		filters:   x.Filters,
+		timestamp: time.Now(),
+	}
+
+	cr := e.getCorrelator(c.Name)
+	var events []correlatedEvent
+	switch cc.Type {
+	case CorrelationCount:
+		events = cr.count(cc, key, ce)
+	case CorrelationSequence:
+		events = cr.sequence(cc, key, ce, hive)
+	case CorrelationAbsence:
+		cr.absence(c, key, ce, hive)
+	}
+
+	if len(events) == 0 {
+		log.Debugln("\t\tEvent correlated, waiting for further events")
+		x.finish(ExecutionPending)
+		return
+	}
+
+	log.Debugln("\t\tCorrelation complete")
+	e.fireChainActions(c, correlationData(m, events), x, w)
+}
+
+// correlationData adds the correlated events to a chain's template data.
+func correlationData(m map[string]interface{}, events []correlatedEvent) map[string]interface{} {
+	ed := []map[string]interface{}{}
+	for _, e := range events {
+		ed = append(ed, e.data)
+	}
+	m["events"] = ed
+
+	return m
+}
+
+// expire drops all events of a key which are older than the window.
+func (cr *chainCorrelator) expire(cc *ChainCorrelation, key string, now time.Time) []correlatedEvent {
+	window := parseDuration(cc.Window, 0)
+
+	events := cr.events[key]
+	for len(events) > 0 && now.Sub(events[0].timestamp) > window {
+		events = events[1:]
+	}
+
+	return events
+}
+
+// sweep drops the events of all keys which didn't see an event within the
+// window. Runs at most once per window. The caller must hold the lock.
+func (cr *chainCorrelator) sweep(cc *ChainCorrelation, now time.Time) {
+	window := parseDuration(cc.Window, 0)
+	if now.Sub(cr.swept) < window {
+		return
+	}
+	cr.swept = now
+
+	for key, events := range cr.events {
+		if len(events) == 0 || now.Sub(events[len(events)-1].timestamp) > window {
+			delete(cr.events, key)
+		}
+	}
+}
+
+// count returns the correlated events once Count of them occurred within the
+// window.
+func (cr *chainCorrelator) count(cc *ChainCorrelation, key string, ce correlatedEvent) []correlatedEvent {
+	cr.Lock()
+	defer cr.Unlock()
+
+	cr.sweep(cc, ce.timestamp)
+	events := append(cr.expire(cc, key, ce.timestamp), ce)
+	if len(events) < cc.Count {
+		cr.events[key] = events
+		return nil
+	}
+
+	delete(cr.events, key)
+	return events
+}
+
+// sequence returns the First and the Then event, once a Then event follows a
+// First event within the window.
+func (cr *chainCorrelator) sequence(cc *ChainCorrelation, key string, ce correlatedEvent, hive string) []correlatedEvent {
+	cr.Lock()
+	defer cr.Unlock()
+
+	cr.sweep(cc, ce.timestamp)
+	events := cr.expire(cc, key, ce.timestamp)
+	if len(events) > 0 && cc.Then.matches(&ce.event, hive) {
+		delete(cr.events, key)
+		return []correlatedEvent{events[len(events)-1], ce}
+	}
+
+	if cc.First.matches(&ce.event, hive) {
+		cr.events[key] = []correlatedEvent{ce}
+	}
+	return nil
+}
+
+// absence waits for a Then event after a First event and fires the chain if
+// none arrived within the window.
+func (cr *chainCorrelator) absence(c Chain, key string, ce correlatedEvent, hive string) {
+	cc := c.Correlation
+
+	cr.Lock()
+	defer cr.Unlock()
+
+	if t, ok := cr.timers[key]; ok && cc.Then.matches(&ce.event, hive) {
+		t.Stop()
+		delete(cr.timers, key)
+		delete(cr.events, key)
+		return
+	}
+	if !cc.First.matches(&ce.event, hive) {
+		return
+	}
+
+	if t, ok := cr.timers[key]; ok {
+		t.Stop()
+	}
+	cr.events[key] = []correlatedEvent{ce}
+	cr.timers[key] = time.AfterFunc(parseDuration(cc.Window, 0), func() {
+		cr.Lock()
+		events := cr.events[key]
+		delete(cr.events, key)
+		delete(cr.timers, key)
+		cr.Unlock()
+
+		if len(events) == 0 {
+			return
+		}
+
+		defer func() {
+			if e := recover(); e != nil {
+				log.Printf("Fatal chain event: %s %s", e, debug.Stack())
+			}
+		}()
+
+		log.Debugln("Executing chain after absent event:", c.Name, "-", c.Description)
+		first := events[0]
+		x := cr.engine.newExecution(c, first.event)
+		x.Filters = first.filters
+
+		m := make(map[string]interface{})
+		for k, v := range first.data {
+			m[k] = v
+		}
+		cr.engine.ctx.FillMap(m)
+		cr.engine.fireChainActions(c, correlationData(m, events), x, nil)
+	})
+}
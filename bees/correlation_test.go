@@ -0,0 +1,125 @@
+package bees
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCorrelation(t *testing.T) {
+	bee := startTestBee("backup-started")
+	defer DeleteBee(GetBee("backup-started"))
+	startTestBee("backup-finished")
+	defer DeleteBee(GetBee("backup-finished"))
+
+	SetActions([]Action{{
+		ID:      "report",
+		Bee:     "backup-started",
+		Name:    "echo",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "{{len .events}}{{range .events}} {{.match.bee}}:{{.text}}{{end}}"}},
+	}})
+	defer SetChains([]Chain{})
+
+	first := &Trigger{Bee: "backup-started"}
+	then := &Trigger{Bee: "backup-finished"}
+	cases := []struct {
+		correlation ChainCorrelation
+		events      [][2]string
+		expected    []string
+	}{
+		{
+			ChainCorrelation{Type: CorrelationCount, Count: 2, Window: "1h", Key: "{{.text}}"},
+			[][2]string{{"backup-started", "a"}, {"backup-started", "b"}, {"backup-finished", "a"}, {"backup-started", "a"}},
+			[]string{"2 backup-started:a backup-finished:a"},
+		},
+		{
+			ChainCorrelation{Type: CorrelationSequence, Window: "1h", First: first, Then: then},
+			[][2]string{{"backup-finished", "a"}, {"backup-started", "b"}, {"backup-finished", "c"}},
+			[]string{"2 backup-started:b backup-finished:c"},
+		},
+		{
+			ChainCorrelation{Type: CorrelationAbsence, Window: "50ms", First: first, Then: then, Key: "{{.text}}"},
+			[][2]string{{"backup-started", "a"}, {"backup-started", "b"}, {"backup-finished", "a"}},
+			[]string{"1 backup-started:b"},
+		},
+	}
+
+	for i, c := range cases {
+		if err := c.correlation.Validate(); err != nil {
+			t.Fatalf("Invalid correlation %d: %v", i, err)
+		}
+		c := c
+		SetChains([]Chain{{
+			Name:        "correlated",
+			Triggers:    []Trigger{*first, *then},
+			Actions:     []string{"report"},
+			Correlation: &c.correlation,
+		}})
+
+		bee.reset()
+		for _, e := range c.events {
+			defaultEngine.execChains(&Event{
+				Bee:     e[0],
+				Name:    "message",
+				Options: Placeholders{{Name: "text", Type: "string", Value: e[1]}},
+			}, nil, nil)
+		}
+		var texts []string
+		for _, a := range bee.waitForActions(len(c.expected)) {
+			texts = append(texts, a.Options.Value("text").(string))
+		}
+		if len(texts) != len(c.expected) || (len(texts) > 0 && texts[0] != c.expected[0]) {
+			t.Errorf("Expected correlation %s to execute %v, got %v", c.correlation.Type, c.expected, texts)
+		}
+	}
+
+	if err := (&ChainCorrelation{Type: CorrelationSequence, Window: "1m"}).Validate(); err == nil {
+		t.Error("Expected a sequence correlation without events to be invalid")
+	}
+}
+
+func TestCorrelationCleanup(t *testing.T) {
+	cc := &ChainCorrelation{Type: CorrelationCount, Count: 2, Window: "20ms", Key: "{{.text}}"}
+	cr := defaultEngine.getCorrelator("cleanup")
+	defer defaultEngine.dropCorrelator("cleanup")
+
+	start := time.Now()
+	cr.count(cc, "idle", correlatedEvent{timestamp: start})
+	cr.count(cc, "busy", correlatedEvent{timestamp: start.Add(30 * time.Millisecond)})
+	if _, ok := cr.events["idle"]; ok {
+		t.Error("Expected the events of an idle key to expire")
+	}
+	if len(cr.events["busy"]) != 1 {
+		t.Errorf("Expected the busy key to keep its event, got %+v", cr.events["busy"])
+	}
+
+	bee := startTestBee("cleanuptest")
+	defer DeleteBee(GetBee("cleanuptest"))
+	SetActions([]Action{{ID: "report", Bee: "cleanuptest", Name: "echo"}})
+	chain := Chain{
+		Name:     "absent",
+		Triggers: []Trigger{{Bee: "cleanuptest"}},
+		Actions:  []string{"report"},
+		Correlation: &ChainCorrelation{
+			Type:   CorrelationAbsence,
+			Window: "50ms",
+			First:  &Trigger{Bee: "cleanuptest", Name: "message"},
+			Then:   &Trigger{Bee: "cleanuptest", Name: "done"},
+		},
+	}
+	SetChains([]Chain{chain})
+	defer SetChains([]Chain{})
+
+	defaultEngine.execChains(&Event{Bee: "cleanuptest", Name: "message"}, nil, nil)
+	edited := *chain.Correlation
+	edited.Window = "1h"
+	chain.Correlation = &edited
+	SetChains([]Chain{chain})
+	if _, ok := defaultEngine.correlators["absent"]; ok {
+		t.Error("Expected editing a chain to drop its correlator")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if n := len(bee.recorded()); n != 0 {
+		t.Errorf("Expected the edited chain not to fire for its old correlation, got %d actions", n)
+	}
+}
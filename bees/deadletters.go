@@ -0,0 +1,141 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"errors"
+	"time"
+)
+
+// maximum amount of dead letters we keep around
+const maxDeadLetters = 1000
+
+// DeadLetter is an action invocation that failed permanently.
+type DeadLetter struct {
+	ID        string
+	Action    Action
+	Error     string
+	Attempts  int
+	Timestamp time.Time
+}
+
+// addDeadLetter stores a failed action invocation. The action is stored with
+// its rendered options, so it can be re-run as is.
+func (e *Engine) addDeadLetter(a Action, err error, attempts int) {
+	e.deadLetterMutex.Lock()
+	defer e.deadLetterMutex.Unlock()
+
+	e.deadLetters = append(e.deadLetters, DeadLetter{
+		ID:        UUID(),
+		Action:    a,
+		Error:     err.Error(),
+		Attempts:  attempts,
+		Timestamp: time.Now(),
+	})
+	if len(e.deadLetters) > maxDeadLetters {
+		e.deadLetters = e.deadLetters[len(e.deadLetters)-maxDeadLetters:]
+	}
+}
+
+// GetDeadLetters returns all dead letters, newest first.
+func GetDeadLetters() []DeadLetter {
+	return defaultEngine.GetDeadLetters()
+}
+
+// GetDeadLetters returns the engine's dead letters.
+func (e *Engine) GetDeadLetters() []DeadLetter {
+	e.deadLetterMutex.RLock()
+	defer e.deadLetterMutex.RUnlock()
+
+	r := []DeadLetter{}
+	for i := len(e.deadLetters) - 1; i >= 0; i-- {
+		r = append(r, e.deadLetters[i])
+	}
+
+	return r
+}
+
+// GetDeadLetter returns the dead letter with a specific ID.
+func GetDeadLetter(id string) *DeadLetter {
+	return defaultEngine.GetDeadLetter(id)
+}
+
+// GetDeadLetter returns the engine's dead letter with a specific ID.
+func (e *Engine) GetDeadLetter(id string) *DeadLetter {
+	e.deadLetterMutex.RLock()
+	defer e.deadLetterMutex.RUnlock()
+
+	for _, dl := range e.deadLetters {
+		if dl.ID == id {
+			return &dl
+		}
+	}
+
+	return nil
+}
+
+// DeleteDeadLetter removes a dead letter from the store. Returns false if no
+// dead letter with this ID exists.
+func DeleteDeadLetter(id string) bool {
+	return defaultEngine.DeleteDeadLetter(id)
+}
+
+// DeleteDeadLetter removes a dead letter from the engine.
+func (e *Engine) DeleteDeadLetter(id string) bool {
+	e.deadLetterMutex.Lock()
+	defer e.deadLetterMutex.Unlock()
+
+	for i, dl := range e.deadLetters {
+		if dl.ID == id {
+			e.deadLetters = append(e.deadLetters[:i], e.deadLetters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// RetryDeadLetter re-runs the action of a dead letter, honoring its retry
+// policy. If it fails again, a new dead letter gets stored.
+func RetryDeadLetter(id string) ([]Placeholder, error) {
+	return defaultEngine.RetryDeadLetter(id)
+}
+
+// RetryDeadLetter re-runs one of the engine's dead letters.
+func (e *Engine) RetryDeadLetter(id string) ([]Placeholder, error) {
+	dl := e.GetDeadLetter(id)
+	if dl == nil {
+		return nil, errors.New("No such dead letter")
+	}
+
+	bee := e.GetBee(dl.Action.Bee)
+	if bee == nil {
+		return nil, errors.New("Bee " + dl.Action.Bee + " not registered")
+	}
+	if !(*bee).IsRunning() {
+		return nil, errors.New("Bee " + dl.Action.Bee + " is not running")
+	}
+
+	e.DeleteDeadLetter(id)
+	(*bee).LogAction()
+	return e.runAction(bee, dl.Action, nil)
+}
@@ -63,11 +63,16 @@ type StateDescriptor struct {
 
 // GetActionDescriptor returns the ActionDescriptor matching an action.
 func GetActionDescriptor(action *Action) ActionDescriptor {
-	bee := GetBee(action.Bee)
+	return defaultEngine.GetActionDescriptor(action)
+}
+
+// GetActionDescriptor returns the ActionDescriptor matching an action of the engine.
+func (e *Engine) GetActionDescriptor(action *Action) ActionDescriptor {
+	bee := e.GetBee(action.Bee)
 	if bee == nil {
 		panic("Bee " + action.Bee + " not registered")
 	}
-	factory := (*GetFactory((*bee).Namespace()))
+	factory := (*e.GetFactory((*bee).Namespace()))
 	for _, ac := range factory.Actions() {
 		if ac.Name == action.Name {
 			return ac
@@ -79,12 +84,17 @@ func GetActionDescriptor(action *Action) ActionDescriptor {
 
 // GetEventDescriptor returns the EventDescriptor matching an event.
 func GetEventDescriptor(event *Event) EventDescriptor {
-	bee := GetBee(event.Bee)
+	return defaultEngine.GetEventDescriptor(event)
+}
+
+// GetEventDescriptor returns the EventDescriptor matching an event of the engine.
+func (e *Engine) GetEventDescriptor(event *Event) EventDescriptor {
+	bee := e.GetBee(event.Bee)
 	if bee == nil {
 		panic("Bee " + event.Bee + " not registered")
 	}
-	factory := (*GetFactory((*bee).Namespace()))
-	for _, ev := range factory.Events() {
+	factory := (*e.GetFactory((*bee).Namespace()))
+	for _, ev := range append(factory.Events(), InternalEvents(factory.ID())...) {
 		if ev.Name == event.Name {
 			return ev
 		}
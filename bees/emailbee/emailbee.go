@@ -22,6 +22,7 @@
 package emailbee
 
 import (
+	"errors"
 	"net"
 
 	"strconv"
@@ -39,18 +40,27 @@ type EmailBee struct {
 	server   string
 }
 
-// Action triggers the action passed to it.
-func (mod *EmailBee) Action(action bees.Action) []bees.Placeholder {
+// ActionWithError triggers the action passed to it.
+func (mod *EmailBee) ActionWithError(action bees.Action) ([]bees.Placeholder, error) {
 	outs := []bees.Placeholder{}
 
 	switch action.Name {
 	case "send":
 		var from, to, plainText, htmlText, subject string
-		action.Options.Bind("sender", &from)
-		action.Options.Bind("recipient", &to)
-		action.Options.Bind("subject", &subject)
-		action.Options.Bind("text", &plainText)
-		action.Options.Bind("html", &htmlText)
+		if err := action.Options.Bind("recipient", &to); err != nil {
+			return outs, err
+		}
+		if err := action.Options.Bind("text", &plainText); err != nil {
+			return outs, err
+		}
+		for name, dst := range map[string]*string{"sender": &from, "subject": &subject, "html": &htmlText} {
+			if action.Options.Value(name) == nil {
+				continue
+			}
+			if err := action.Options.Bind(name, dst); err != nil {
+				return outs, err
+			}
+		}
 
 		m := mail.NewMessage()
 		if len(from) > 0 {
@@ -77,21 +87,21 @@ func (mod *EmailBee) Action(action bees.Action) []bees.Placeholder {
 		if len(mod.username) > 0 && len(mod.password) > 0 {
 			// With authentication
 			if err := mail.NewDialer(host, port, mod.username, mod.password).DialAndSend(m); err != nil {
-				panic(err)
+				return outs, err
 			}
 		} else {
 			// No Auth
 			d := mail.Dialer{Host: host, Port: port}
 			if err := d.DialAndSend(m); err != nil {
-				panic(err)
+				return outs, err
 			}
 		}
 
 	default:
-		panic("Unknown action triggered in " + mod.Name() + ": " + action.Name)
+		return outs, errors.New("Unknown action triggered in " + mod.Name() + ": " + action.Name)
 	}
 
-	return outs
+	return outs, nil
 }
 
 // ReloadOptions parses the config options and initializes the Bee.
@@ -0,0 +1,130 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"sync"
+)
+
+// Engine is an isolated Beehive instance. It owns its own registry of bees,
+// chains and actions, its event loop, context and log store, as well as the
+// execution history, dead letters and scheduled actions of its chains. The
+// package-level functions operate on a default engine.
+//
+// Metrics are collected process-wide and aren't split up per engine.
+type Engine struct {
+	registry *Registry
+	ctx      *Context
+	queue    *DiskQueue
+
+	logStore LogStore
+	logMutex sync.RWMutex
+
+	eventsIn  chan Event
+	loopWake  chan struct{}
+	loopDone  chan struct{}
+	loopMutex sync.RWMutex
+
+	emitted      []QueuedEvent
+	emittedMutex sync.Mutex
+
+	workerConfig WorkerPoolConfig
+	workers      *workerPool
+	workerMutex  sync.RWMutex
+
+	subscriptions     map[*Subscription]struct{}
+	subscriptionMutex sync.RWMutex
+
+	executions     []Execution
+	executionMutex sync.RWMutex
+
+	deadLetters     []DeadLetter
+	deadLetterMutex sync.RWMutex
+
+	limiters     map[string]*chainLimiter
+	limiterMutex sync.Mutex
+
+	correlators     map[string]*chainCorrelator
+	correlatorMutex sync.Mutex
+
+	scheduled     map[string]*scheduledEntry
+	scheduleMutex sync.Mutex
+	schedulePath  string
+}
+
+var (
+	defaultEngine = newEngine()
+)
+
+// NewEngine returns a new, isolated engine. It knows about all hives that
+// registered themselves with the default engine.
+func NewEngine() *Engine {
+	e := newEngine()
+	for _, factory := range defaultEngine.registry.Factories() {
+		e.registry.RegisterFactory(*factory)
+	}
+
+	return e
+}
+
+func newEngine() *Engine {
+	// the event loop doesn't run until the bees get started
+	done := make(chan struct{})
+	close(done)
+
+	return &Engine{
+		registry:      NewRegistry(),
+		ctx:           NewContext(),
+		eventsIn:      make(chan Event),
+		loopWake:      make(chan struct{}, 1),
+		loopDone:      done,
+		logStore:      NewMemoryLogStore(DefaultLogsPerBee),
+		workers:       newWorkerPool(WorkerPoolConfig{}),
+		subscriptions: make(map[*Subscription]struct{}),
+		limiters:      make(map[string]*chainLimiter),
+		correlators:   make(map[string]*chainCorrelator),
+		scheduled:     make(map[string]*scheduledEntry),
+	}
+}
+
+// DefaultEngine returns the engine used by the package-level functions.
+func DefaultEngine() *Engine {
+	return defaultEngine
+}
+
+// Registry returns the engine's registry of bees, factories, chains and
+// actions.
+func (e *Engine) Registry() *Registry {
+	return e.registry
+}
+
+// engineSetter is implemented by all bees embedding Bee.
+type engineSetter interface {
+	setEngine(e *Engine)
+}
+
+// attach makes a bee report its logs, context & events to the engine.
+func (e *Engine) attach(bee BeeInterface) {
+	if s, ok := bee.(engineSetter); ok {
+		s.setEngine(e)
+	}
+}
@@ -0,0 +1,67 @@
+package bees
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEngineIsolation(t *testing.T) {
+	engines := []*Engine{NewEngine(), NewEngine()}
+	for _, e := range engines {
+		e.StartBees([]BeeConfig{{Name: "tenant", Class: "testbee"}})
+		defer e.StopBees()
+
+		e.SetActions([]Action{
+			{
+				ID:      "echo",
+				Bee:     "tenant",
+				Name:    "echo",
+				Options: Placeholders{{Name: "text", Type: "string", Value: "{{.text}}"}},
+			},
+		})
+		e.SetChains([]Chain{
+			{
+				Name:    "tenantchain",
+				Event:   &Event{Bee: "tenant", Name: "message"},
+				Actions: []string{"echo"},
+			},
+		})
+	}
+	a, b := engines[0], engines[1]
+
+	if GetBee("tenant") != nil || GetChain("tenantchain") != nil {
+		t.Fatal("Engine leaked bees or chains into the default engine")
+	}
+
+	err := a.InjectEvent(&Event{
+		Bee:     "tenant",
+		Name:    "message",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "hello"}},
+	})
+	if err != nil {
+		t.Fatalf("Error injecting event: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(a.GetExecutions(ExecutionQuery{})) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the chain to execute")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := len(b.GetExecutions(ExecutionQuery{})); n != 0 {
+		t.Errorf("Expected no executions in the other engine, got %d", n)
+	}
+	if n := len((*b.GetBee("tenant")).(*testBee).recorded()); n != 0 {
+		t.Errorf("Expected the other engine's bee to stay idle, got %d actions", n)
+	}
+	if n := len((*a.GetBee("tenant")).(*testBee).recorded()); n != 1 {
+		t.Errorf("Expected 1 action, got %d", n)
+	}
+
+	(*a.GetBee("tenant")).Logf("tenant a")
+	if len(a.GetLogs("tenant")) != 1 || len(b.GetLogs("tenant")) != 0 || len(GetLogs("tenant")) != 0 {
+		t.Errorf("Expected log messages to stay within their engine")
+	}
+}
@@ -22,8 +22,11 @@
 package bees
 
 import (
+	"errors"
 	"fmt"
 	"runtime/debug"
+	"sync/atomic"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -33,41 +36,288 @@ type Event struct {
 	Bee     string
 	Name    string
 	Options Placeholders
+	// Hops counts how many chains emitted this event in a row, see
+	// ChainOutput
+	Hops int `json:",omitempty" yaml:",omitempty"`
 }
 
-var (
-	eventsIn = make(chan Event)
-)
+// ValidateEvent checks an event against the EventDescriptor of its hive and
+// converts its placeholders to the described types.
+func ValidateEvent(event *Event) error {
+	return defaultEngine.ValidateEvent(event)
+}
+
+// ValidateEvent checks an event against the engine's hives.
+func (e *Engine) ValidateEvent(event *Event) error {
+	bee := e.GetBee(event.Bee)
+	if bee == nil {
+		return errors.New("Bee " + event.Bee + " not registered")
+	}
+
+	desc := e.GetEventDescriptor(event)
+	if desc.Name != event.Name {
+		return errors.New("Bee " + event.Bee + " does not provide an event named " + event.Name)
+	}
+
+	phs := Placeholders{}
+	for _, ph := range event.Options {
+		var pd *PlaceholderDescriptor
+		for _, d := range desc.Options {
+			if d.Name == ph.Name {
+				d := d
+				pd = &d
+				break
+			}
+		}
+		if pd == nil {
+			return errors.New("Event " + event.Name + " has no placeholder named " + ph.Name)
+		}
+
+		v, err := convertPlaceholder(ph.Value, pd.Type)
+		if err != nil {
+			return fmt.Errorf("Invalid value for placeholder %s: %v", ph.Name, err)
+		}
+		phs = append(phs, Placeholder{Name: ph.Name, Type: pd.Type, Value: v})
+	}
+
+	for _, d := range desc.Options {
+		if d.Mandatory && phs.Value(d.Name) == nil {
+			return errors.New("Mandatory placeholder " + d.Name + " is missing")
+		}
+	}
+
+	event.Options = phs
+	return nil
+}
+
+// convertPlaceholder converts v to the Go type matching a placeholder type.
+// Unknown types are passed through as is.
+func convertPlaceholder(v interface{}, t string) (r interface{}, err error) {
+	switch t {
+	case "string", "url", "address", "password":
+		var s string
+		err = ConvertValue(v, &s)
+		return s, err
+	case "[]string":
+		var s []string
+		err = ConvertValue(v, &s)
+		return s, err
+	case "int":
+		var i int
+		err = ConvertValue(v, &i)
+		return i, err
+	case "float64":
+		var f float64
+		err = ConvertValue(v, &f)
+		return f, err
+	case "bool":
+		var b bool
+		err = ConvertValue(v, &b)
+		return b, err
+	case "timestamp", "time.Time":
+		var ts time.Time
+		err = ConvertValue(v, &ts)
+		return ts, err
+	}
 
-// handleEvents handles incoming events and executes matching Chains.
-func handleEvents() {
+	return v, nil
+}
+
+// InjectEvent validates an event and hands it to the event handler, exactly
+// as if the bee had emitted it. The event's placeholders get converted in
+// place, see ValidateEvent.
+func InjectEvent(event *Event) error {
+	return defaultEngine.InjectEvent(event)
+}
+
+// InjectEvent hands an event to the engine's event loop.
+func (e *Engine) InjectEvent(event *Event) error {
+	err := e.ValidateEvent(event)
+	if err != nil {
+		return err
+	}
+
+	events, done := e.loop()
+	select {
+	case events <- *event:
+		return nil
+	case <-done:
+		return errors.New("Event handler is not running")
+	}
+}
+
+// startEventLoop starts a new event loop for the engine.
+func (e *Engine) startEventLoop() {
+	e.loopMutex.Lock()
+	e.eventsIn = make(chan Event)
+	e.loopWake = make(chan struct{}, 1)
+	e.loopDone = make(chan struct{})
+	events, wake, done := e.eventsIn, e.loopWake, e.loopDone
+	e.loopMutex.Unlock()
+
+	// pick up events emitted while the previous loop shut down
+	wake <- struct{}{}
+
+	go e.handleEvents(events, wake, done)
+}
+
+// stopEventLoop shuts down the engine's event loop. Events sent to it
+// afterwards get dropped.
+func (e *Engine) stopEventLoop() {
+	e.loopMutex.Lock()
+	defer e.loopMutex.Unlock()
+
+	select {
+	case <-e.loopDone:
+	default:
+		close(e.loopDone)
+	}
+}
+
+// loop returns the channel of the engine's current event loop and a channel
+// which gets closed once the loop stopped.
+func (e *Engine) loop() (chan Event, chan struct{}) {
+	e.loopMutex.RLock()
+	defer e.loopMutex.RUnlock()
+
+	return e.eventsIn, e.loopDone
+}
+
+// handleEvents handles incoming events and executes matching Chains, until
+// done gets closed. Events emitted by the engine itself wait in a backlog,
+// which wake signals to be non-empty, so workers never wait for the event
+// loop.
+func (e *Engine) handleEvents(events chan Event, wake chan struct{}, done chan struct{}) {
 	for {
-		event, ok := <-eventsIn
-		if !ok {
+		select {
+		case event := <-events:
+			e.processEvent(e.persistEvent(event), event)
+		case <-wake:
+			for _, qe := range e.takeEmitted() {
+				e.processEvent(qe.ID, qe.Event)
+			}
+		case <-done:
 			log.Println()
 			log.Println("Stopped event handler!")
-			break
+			return
 		}
+	}
+}
 
-		bee := GetBee(event.Bee)
-		(*bee).LogEvent()
+// persistEvent stores an event in the persistent queue, if there is one, and
+// returns its ID in the queue.
+func (e *Engine) persistEvent(event Event) string {
+	if e.queue == nil {
+		return ""
+	}
 
-		log.Debugln()
-		log.Debugln("Event received:", event.Bee, "/", event.Name, "-", GetEventDescriptor(&event).Description)
-		for _, v := range event.Options {
-			vv := truncateString(fmt.Sprintln(v), 1000)
-			log.Debugln("\tOptions:", vv)
-		}
+	id, err := e.queue.Push(event)
+	if err != nil {
+		log.Errorln("Failed to persist event:", err)
+	}
+	return id
+}
 
-		go func() {
-			defer func() {
-				if e := recover(); e != nil {
-					log.Printf("Fatal chain event: %s %s", e, debug.Stack())
-				}
-			}()
+// takeEmitted returns and clears the backlog of events emitted by the engine.
+func (e *Engine) takeEmitted() []QueuedEvent {
+	e.emittedMutex.Lock()
+	defer e.emittedMutex.Unlock()
+
+	r := e.emitted
+	e.emitted = nil
+	return r
+}
+
+// redeliverEvents processes all events which were still pending when the
+// persistent event queue got opened.
+func (e *Engine) redeliverEvents() {
+	if e.queue == nil {
+		return
+	}
+
+	for _, qe := range e.queue.Restored() {
+		log.Println("Redelivering event:", qe.Event.Bee, "/", qe.Event.Name)
+		e.processEvent(qe.ID, qe.Event)
+	}
+}
+
+// processEvent hands an event to the worker pool, which executes all its
+// chains. If the event is stored in the persistent queue, it gets
+// acknowledged once all executions it caused are finished, including
+// debounced ones.
+func (e *Engine) processEvent(id string, event Event) {
+	bee := e.GetBee(event.Bee)
+	if bee == nil && !IsChainEvent(&event) {
+		log.Errorln("Received event from unknown bee:", event.Bee)
+		e.ackEvent(id)
+		return
+	}
+	description := "emitted by chain"
+	if bee != nil {
+		(*bee).LogEvent()
+		description = e.GetEventDescriptor(&event).Description
+	}
+	eventsTotal.WithLabelValues(event.Bee, event.Name).Inc()
+	lastEvent.WithLabelValues(event.Bee).SetToCurrentTime()
+	e.publish(StreamMessage{Type: StreamEvent, Event: &event})
 
-			execChains(&event)
+	log.Debugln()
+	log.Debugln("Event received:", event.Bee, "/", event.Name, "-", description)
+	for _, v := range event.Options {
+		vv := truncateString(fmt.Sprintln(v), 1000)
+		log.Debugln("\tOptions:", vv)
+	}
+
+	ack := &pendingAck{pending: 1, ack: func() {
+		e.ackEvent(id)
+	}}
+	job := func(w *worker) {
+		defer ack.done()
+		defer func() {
+			if e := recover(); e != nil {
+				log.Printf("Fatal chain event: %s %s", e, debug.Stack())
+			}
 		}()
+
+		e.execChains(&event, ack, w)
+	}
+
+	p := e.pool()
+	if !p.submit(e.orderingKey(&event, p.config), job) {
+		// the engine is shutting down
+		go job(nil)
+	}
+}
+
+// ackEvent acknowledges an event in the persistent queue.
+func (e *Engine) ackEvent(id string) {
+	if e.queue == nil || id == "" {
+		return
+	}
+
+	err := e.queue.Ack(id)
+	if err != nil {
+		log.Errorln("Failed to acknowledge event:", err)
+	}
+}
+
+// pendingAck calls ack once all executions tracking it are finished.
+type pendingAck struct {
+	pending int32
+	ack     func()
+}
+
+// add tracks another execution.
+func (a *pendingAck) add() {
+	if a != nil {
+		atomic.AddInt32(&a.pending, 1)
+	}
+}
+
+// done marks an execution as finished.
+func (a *pendingAck) done() {
+	if a != nil && atomic.AddInt32(&a.pending, -1) == 0 {
+		a.ack()
 	}
 }
 
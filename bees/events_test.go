@@ -0,0 +1,63 @@
+package bees
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateEvent(t *testing.T) {
+	startTestBee("eventtest")
+	defer DeleteBee(GetBee("eventtest"))
+
+	cases := []struct {
+		event Event
+		valid bool
+	}{
+		{Event{Bee: "eventtest", Name: "message", Options: Placeholders{{Name: "text", Value: "hi"}}}, true},
+		{Event{Bee: "eventtest", Name: "message", Options: Placeholders{{Name: "text", Value: "hi"}, {Name: "count", Value: "3"}}}, true},
+		{Event{Bee: "eventtest", Name: "message", Options: Placeholders{{Name: "count", Value: 3}}}, false},
+		{Event{Bee: "eventtest", Name: "message", Options: Placeholders{{Name: "text", Value: "hi"}, {Name: "unknown", Value: 3}}}, false},
+		{Event{Bee: "eventtest", Name: "unknown"}, false},
+		{Event{Bee: "unknown", Name: "message"}, false},
+	}
+	for i := range cases {
+		err := ValidateEvent(&cases[i].event)
+		if (err == nil) != cases[i].valid {
+			t.Errorf("Unexpected validation result for %+v: %v", cases[i].event, err)
+		}
+	}
+
+	e := cases[1].event
+	if v, ok := e.Options.Value("count").(int); !ok || v != 3 {
+		t.Errorf("Expected placeholder to be converted to its described type, got %#v", e.Options.Value("count"))
+	}
+}
+
+func TestInjectEvent(t *testing.T) {
+	e := NewEngine()
+	bee := startEngineTestBee(e, "injecttest")
+	defer e.DeleteBee(e.GetBee("injecttest"))
+
+	e.SetActions([]Action{{ID: "injected", Bee: "injecttest", Name: "echo"}})
+	e.SetChains([]Chain{{
+		Name:    "injected",
+		Event:   &Event{Bee: "injecttest", Name: "message"},
+		Actions: []string{"injected"},
+	}})
+	defer e.SetChains([]Chain{})
+
+	e.startEventLoop()
+	defer e.stopEventLoop()
+
+	err := e.InjectEvent(&Event{Bee: "injecttest", Name: "message", Options: Placeholders{{Name: "text", Value: "hi"}}})
+	if err != nil {
+		t.Fatalf("Error injecting event: %v", err)
+	}
+
+	for i := 0; i < 100 && len(e.GetExecutions(ExecutionQuery{Chain: "injected"})) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(bee.recorded()) != 1 {
+		t.Errorf("Expected the injected event to trigger the chain, got %d actions", len(bee.recorded()))
+	}
+}
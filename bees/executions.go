@@ -0,0 +1,278 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"time"
+)
+
+const (
+	// maximum amount of executions we keep in the history
+	maxExecutions = 1000
+	// maximum age of executions we keep in the history
+	maxExecutionAge = 24 * time.Hour
+
+	// replaces the values of password placeholders in recorded executions
+	redactedValue = "********"
+)
+
+// Possible states of an Execution
+const (
+	ExecutionFiltered   = "filtered"
+	ExecutionSuppressed = "suppressed"
+	ExecutionCompleted  = "completed"
+	ExecutionFailed     = "failed"
+	ExecutionSimulated  = "simulated"
+	ExecutionPending    = "pending"
+)
+
+// Execution is the trace of a single chain run.
+type Execution struct {
+	ID        string
+	Chain     string
+	Event     Event
+	Status    string
+	Filters   []FilterTrace
+	Actions   []ActionTrace
+	Timestamp time.Time
+	Duration  time.Duration
+	// Origin is the ID of the execution which scheduled this execution's
+	// action, if it ran as a scheduled action
+	Origin string
+
+	engine *Engine
+	ack    *pendingAck
+}
+
+// FilterTrace records the outcome of a filter.
+type FilterTrace struct {
+	Filter   string
+	Rendered string
+	Passed   bool
+	Error    string
+	// Branch is the 1-based index of the branch this condition guards, or 0
+	// for the chain's filters
+	Branch int
+	// Transform is the name of the field a transform derived. Filter then
+	// contains the transform's template
+	Transform string
+}
+
+// ActionTrace records the execution of an action.
+type ActionTrace struct {
+	ID           string
+	Bee          string
+	Name         string
+	Options      Placeholders
+	Placeholders Placeholders
+	Skipped      bool
+	OnError      bool
+	Scheduled    time.Time
+	Error        string
+	Timestamp    time.Time
+	Duration     time.Duration
+}
+
+// ExecutionQuery selects executions from the history. Empty fields match
+// all executions.
+type ExecutionQuery struct {
+	Chain string
+	Bee   string
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+func (e *Engine) newExecution(c Chain, event Event) *Execution {
+	return &Execution{
+		ID:        UUID(),
+		Chain:     c.Name,
+		Event:     event,
+		Timestamp: time.Now(),
+		engine:    e,
+	}
+}
+
+// complete sets the final status and duration of the execution.
+func (x *Execution) complete(status string) {
+	x.Status = status
+	x.Duration = time.Since(x.Timestamp)
+}
+
+// finish completes the execution and stores it in the history. Password
+// placeholders get redacted before the execution gets recorded.
+func (x *Execution) finish(status string) {
+	x.complete(status)
+
+	// the event which caused this execution may get acknowledged now
+	ack := x.ack
+	x.ack = nil
+	defer ack.done()
+
+	chainExecutionsTotal.WithLabelValues(x.Chain, status).Inc()
+
+	e := x.engine
+	c := e.redactExecution(*x)
+	e.publish(StreamMessage{Type: StreamExecution, Execution: &c})
+
+	e.executionMutex.Lock()
+	defer e.executionMutex.Unlock()
+
+	e.executions = append(e.executions, c)
+
+	// enforce the retention policy
+	if len(e.executions) > maxExecutions {
+		e.executions = e.executions[len(e.executions)-maxExecutions:]
+	}
+	cutoff := time.Now().Add(-maxExecutionAge)
+	for len(e.executions) > 0 && e.executions[0].Timestamp.Before(cutoff) {
+		e.executions = e.executions[1:]
+	}
+}
+
+// redactExecution returns a copy of an execution with the values of its
+// password placeholders masked: those typed "password" and those an event's
+// or action's descriptor declares as such.
+func (e *Engine) redactExecution(x Execution) Execution {
+	x.Event.Options = redactPlaceholders(x.Event.Options, e.passwordOptions(x.Event.Bee, func(f BeeFactoryInterface) []PlaceholderDescriptor {
+		for _, ev := range append(f.Events(), InternalEvents(f.ID())...) {
+			if ev.Name == x.Event.Name {
+				return ev.Options
+			}
+		}
+		return nil
+	}))
+
+	actions := make([]ActionTrace, 0, len(x.Actions))
+	for _, a := range x.Actions {
+		passwords := e.passwordOptions(a.Bee, func(f BeeFactoryInterface) []PlaceholderDescriptor {
+			for _, ac := range f.Actions() {
+				if ac.Name == a.Name {
+					return ac.Options
+				}
+			}
+			return nil
+		})
+		a.Options = redactPlaceholders(a.Options, passwords)
+		a.Placeholders = redactPlaceholders(a.Placeholders, passwords)
+		actions = append(actions, a)
+	}
+	x.Actions = actions
+
+	return x
+}
+
+// passwordOptions returns the names of the password options a bee's factory
+// describes.
+func (e *Engine) passwordOptions(bee string, options func(f BeeFactoryInterface) []PlaceholderDescriptor) map[string]bool {
+	b := e.GetBee(bee)
+	if b == nil {
+		return nil
+	}
+	f := e.GetFactory((*b).Namespace())
+	if f == nil {
+		return nil
+	}
+
+	r := make(map[string]bool)
+	for _, opt := range options(*f) {
+		if opt.Type == "password" {
+			r[opt.Name] = true
+		}
+	}
+
+	return r
+}
+
+// redactPlaceholders returns phs with the values of all password placeholders
+// masked. phs itself doesn't get modified.
+func redactPlaceholders(phs Placeholders, passwords map[string]bool) Placeholders {
+	var r Placeholders
+	for i, ph := range phs {
+		if ph.Type != "password" && !passwords[ph.Name] {
+			continue
+		}
+		if r == nil {
+			r = append(Placeholders{}, phs...)
+		}
+		r[i].Value = redactedValue
+	}
+
+	if r == nil {
+		return phs
+	}
+	return r
+}
+
+// GetExecutions returns all executions matching the query, newest first.
+func GetExecutions(q ExecutionQuery) []Execution {
+	return defaultEngine.GetExecutions(q)
+}
+
+// GetExecutions returns the engine's chain executions matching a query.
+func (e *Engine) GetExecutions(q ExecutionQuery) []Execution {
+	e.executionMutex.RLock()
+	defer e.executionMutex.RUnlock()
+
+	r := []Execution{}
+	for i := len(e.executions) - 1; i >= 0; i-- {
+		x := e.executions[i]
+		if len(q.Chain) > 0 && x.Chain != q.Chain {
+			continue
+		}
+		if len(q.Bee) > 0 && x.Event.Bee != q.Bee {
+			continue
+		}
+		if !q.Since.IsZero() && x.Timestamp.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && x.Timestamp.After(q.Until) {
+			continue
+		}
+
+		r = append(r, x)
+		if q.Limit > 0 && len(r) >= q.Limit {
+			break
+		}
+	}
+
+	return r
+}
+
+// GetExecution returns the execution with a specific ID.
+func GetExecution(id string) *Execution {
+	return defaultEngine.GetExecution(id)
+}
+
+// GetExecution returns the engine's chain execution with a specific ID.
+func (e *Engine) GetExecution(id string) *Execution {
+	e.executionMutex.RLock()
+	defer e.executionMutex.RUnlock()
+
+	for _, x := range e.executions {
+		if x.ID == id {
+			return &x
+		}
+	}
+
+	return nil
+}
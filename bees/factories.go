@@ -111,25 +111,30 @@ func RegisterFactory(factory BeeFactoryInterface) {
 	}
 	log.Println() */
 
-	factories[factory.ID()] = &factory
+	defaultEngine.RegisterFactory(factory)
+}
+
+// RegisterFactory makes a bee factory available to the engine only.
+func (e *Engine) RegisterFactory(factory BeeFactoryInterface) {
+	e.registry.RegisterFactory(factory)
 }
 
 // GetFactory returns the factory with a specific name.
 func GetFactory(identifier string) *BeeFactoryInterface {
-	factory, ok := factories[identifier]
-	if ok {
-		return factory
-	}
+	return defaultEngine.GetFactory(identifier)
+}
 
-	return nil
+// GetFactory returns the engine's factory with a specific name.
+func (e *Engine) GetFactory(identifier string) *BeeFactoryInterface {
+	return e.registry.Factory(identifier)
 }
 
 // GetFactories returns all known bee factories.
 func GetFactories() []*BeeFactoryInterface {
-	r := []*BeeFactoryInterface{}
-	for _, factory := range factories {
-		r = append(r, factory)
-	}
+	return defaultEngine.GetFactories()
+}
 
-	return r
+// GetFactories returns all bee factories known to the engine.
+func (e *Engine) GetFactories() []*BeeFactoryInterface {
+	return e.registry.Factories()
 }
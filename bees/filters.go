@@ -22,6 +22,8 @@
 package bees
 
 import (
+	"fmt"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/muesli/beehive/filters"
@@ -34,16 +36,31 @@ type Filter struct {
 	Options FilterOption
 }
 
-// execFilter executes a filter. Returns whether the filter passed or not.
-func execFilter(filter string, opts map[string]interface{}) bool {
+// execFilter executes a filter. The returned trace tells whether the filter
+// passed or not.
+func execFilter(filter string, opts map[string]interface{}) (trace FilterTrace) {
+	trace.Filter = filter
 	f := *filters.GetFilter("template")
 	log.Println("\tExecuting filter:", filter)
 
 	defer func() {
 		if e := recover(); e != nil {
 			log.Println("Fatal filter event:", e)
+			trace.Passed = false
+			trace.Error = fmt.Sprint(e)
 		}
 	}()
 
-	return f.Passes(opts, filter)
+	if ef, ok := f.(filters.EvaluatingFilter); ok {
+		passed, rendered, err := ef.Evaluate(opts, filter)
+		if err != nil {
+			panic(err)
+		}
+		trace.Passed = passed
+		trace.Rendered = rendered
+		return trace
+	}
+
+	trace.Passed = f.Passes(opts, filter)
+	return trace
 }
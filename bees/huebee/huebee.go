@@ -22,6 +22,7 @@
 package huebee
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 
@@ -42,8 +43,8 @@ type HueBee struct {
 	bridge string
 }
 
-// Action triggers the action passed to it.
-func (mod *HueBee) Action(action bees.Action) []bees.Placeholder {
+// ActionWithError triggers the action passed to it.
+func (mod *HueBee) ActionWithError(action bees.Action) ([]bees.Placeholder, error) {
 	outs := []bees.Placeholder{}
 
 	switch action.Name {
@@ -52,14 +53,21 @@ func (mod *HueBee) Action(action bees.Action) []bees.Placeholder {
 		var color string
 		var alert int
 		brightness := 254
-		action.Options.Bind("light", &lightID)
-		action.Options.Bind("color", &color)
-		action.Options.Bind("brightness", &brightness)
-		action.Options.Bind("alert", &alert)
+		if err := action.Options.Bind("light", &lightID); err != nil {
+			return outs, err
+		}
+		for name, dst := range map[string]interface{}{"color": &color, "brightness": &brightness, "alert": &alert} {
+			if action.Options.Value(name) == nil {
+				continue
+			}
+			if err := action.Options.Bind(name, dst); err != nil {
+				return outs, err
+			}
+		}
 
 		light, err := mod.client.FindLightById(strconv.Itoa(lightID))
 		if err != nil {
-			panic(err)
+			return outs, err
 		}
 
 		state := hue.SetLightState{
@@ -110,15 +118,18 @@ func (mod *HueBee) Action(action bees.Action) []bees.Placeholder {
 				// RGB color
 				hex, err = colorful.Hex(color)
 				if err != nil {
-					return outs
+					return outs, err
 				}
 			} else {
 				cols := palette.Wikipedia.Filter(color)
+				if len(cols) == 0 {
+					return outs, errors.New("Unknown color " + color)
+				}
 
 				var ok bool
 				hex, ok = colorful.MakeColor(cols[0].Color)
 				if !ok {
-					return outs
+					return outs, errors.New("Invalid color " + color)
 				}
 			}
 			cx, cy, cz := hex.Xyz()
@@ -127,30 +138,39 @@ func (mod *HueBee) Action(action bees.Action) []bees.Placeholder {
 			state.Xy = append(state.Xy, float32(cx))
 			state.Xy = append(state.Xy, float32(cy))
 		}
-		light.SetState(state)
+		if _, err := light.SetState(state); err != nil {
+			return outs, err
+		}
 
 	case "switch":
 		var lightId int
 		var state bool
-		action.Options.Bind("light", &lightId)
-		action.Options.Bind("state", &state)
+		if err := action.Options.Bind("light", &lightId); err != nil {
+			return outs, err
+		}
+		if err := action.Options.Bind("state", &state); err != nil {
+			return outs, err
+		}
 
 		light, err := mod.client.FindLightById(strconv.Itoa(lightId))
 		if err != nil {
-			panic(err)
+			return outs, err
 		}
 
 		if state {
-			light.On()
+			_, err = light.On()
 		} else {
-			light.Off()
+			_, err = light.Off()
+		}
+		if err != nil {
+			return outs, err
 		}
 
 	default:
-		panic("Unknown action triggered in " + mod.Name() + ": " + action.Name)
+		return outs, errors.New("Unknown action triggered in " + mod.Name() + ": " + action.Name)
 	}
 
-	return outs
+	return outs, nil
 }
 
 // ReloadOptions parses the config options and initializes the Bee.
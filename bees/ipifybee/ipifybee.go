@@ -37,9 +37,14 @@ func (mod *IpifyBee) getIP(oldIP string, eventChan chan bees.Event) string {
 	if err != nil {
 		ip, err = ipify.GetIp()
 		if err != nil {
-			panic(err)
+			mod.LogErrorf("Failed retrieving public IP: %v", err)
+			mod.SetState(bees.BeeDegraded, err)
+			return oldIP
 		}
 	}
+	if mod.Status().State == bees.BeeDegraded {
+		mod.SetState(bees.BeeRunning, nil)
+	}
 
 	if oldIP != ip {
 		ev := bees.Event{
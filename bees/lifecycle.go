@@ -0,0 +1,271 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BeeState is a state in a bee's lifecycle.
+type BeeState string
+
+// The states a bee can be in
+const (
+	// BeeStarting bees have been started, but aren't running yet
+	BeeStarting BeeState = "starting"
+	// BeeRunning bees are up and working
+	BeeRunning BeeState = "running"
+	// BeeDegraded bees are running, but failed to execute their last action
+	BeeDegraded BeeState = "degraded"
+	// BeeBackoff bees crashed and are waiting to be restarted
+	BeeBackoff BeeState = "backoff"
+	// BeeFailed bees crashed too often in a row and won't be restarted
+	BeeFailed BeeState = "failed"
+	// BeeStopped bees have been stopped
+	BeeStopped BeeState = "stopped"
+)
+
+// BeeStateChangedEvent is the name of the internal event every bee emits
+// when its state changes.
+const BeeStateChangedEvent = "bee_state_changed"
+
+var (
+	// MaxBeeRestarts is how often a bee gets restarted in a row before it's
+	// considered failed. A bee that kept running for a while gets a fresh
+	// set of restarts
+	MaxBeeRestarts = 10
+
+	beeRestartBackoff    = time.Second
+	beeRestartMaxBackoff = 5 * time.Minute
+)
+
+// BeeStatus describes a bee's current lifecycle state.
+type BeeStatus struct {
+	State         BeeState
+	Since         time.Time
+	LastError     string
+	LastErrorTime time.Time
+	Restarts      int
+}
+
+type beeHealth struct {
+	sync.Mutex
+	status BeeStatus
+}
+
+// stopper is implemented by all bees embedding Bee.
+type stopper interface {
+	stopChan() chan bool
+}
+
+// Status returns the bee's current lifecycle state.
+func (bee *Bee) Status() BeeStatus {
+	if bee.health == nil {
+		return BeeStatus{}
+	}
+
+	bee.health.Lock()
+	defer bee.health.Unlock()
+
+	return bee.health.status
+}
+
+// SetState changes the bee's lifecycle state, recording err as its last
+// error. Other bees' chains get notified via a bee_state_changed event.
+func (bee *Bee) SetState(state BeeState, err error) {
+	if bee.health == nil {
+		return
+	}
+
+	bee.health.Lock()
+	s := &bee.health.status
+	previous := s.State
+	if err != nil {
+		s.LastError = err.Error()
+		s.LastErrorTime = time.Now()
+	}
+	if state == BeeBackoff {
+		s.Restarts++
+	}
+	if state != previous {
+		s.State = state
+		s.Since = time.Now()
+	}
+	status := *s
+	bee.health.Unlock()
+
+	if state == previous {
+		return
+	}
+
+	log.Debugln("Bee", bee.Name(), "changed its state from", previous, "to", state)
+	bee.owner().emitEvent(Event{
+		Bee:  bee.Name(),
+		Name: BeeStateChangedEvent,
+		Options: []Placeholder{
+			{Name: "bee", Type: "string", Value: bee.Name()},
+			{Name: "state", Type: "string", Value: string(state)},
+			{Name: "previous", Type: "string", Value: string(previous)},
+			{Name: "error", Type: "string", Value: status.LastError},
+			{Name: "restarts", Type: "int", Value: status.Restarts},
+		},
+	})
+}
+
+func (bee *Bee) stopChan() chan bool {
+	return bee.SigChan
+}
+
+// beeStateChangedDescriptor describes the internal event emitted whenever a
+// bee changes its state.
+func beeStateChangedDescriptor(namespace string) EventDescriptor {
+	return EventDescriptor{
+		Namespace:   namespace,
+		Name:        BeeStateChangedEvent,
+		Description: "The bee's state changed, e.g. because it crashed",
+		Options: []PlaceholderDescriptor{
+			{Name: "bee", Description: "Name of the bee", Type: "string"},
+			{Name: "state", Description: "New state of the bee", Type: "string"},
+			{Name: "previous", Description: "Previous state of the bee", Type: "string"},
+			{Name: "error", Description: "Last error the bee encountered", Type: "string"},
+			{Name: "restarts", Description: "How often the bee has been restarted", Type: "int"},
+		},
+	}
+}
+
+// InternalEvents returns the events every bee emits, in addition to the ones
+// defined by its hive.
+func InternalEvents(namespace string) []EventDescriptor {
+	return []EventDescriptor{beeStateChangedDescriptor(namespace)}
+}
+
+// emitEvent hands an event emitted by the engine itself to the event loop.
+// Never blocks: it gets called from workers, which the event loop might be
+// waiting for. Instead, the event gets persisted, if the persistent queue is
+// enabled, and waits in a backlog until the loop picks it up. Events emitted
+// while the loop isn't running only survive in the persistent queue.
+func (e *Engine) emitEvent(event Event) {
+	id := e.persistEvent(event)
+
+	e.loopMutex.RLock()
+	wake, done := e.loopWake, e.loopDone
+	e.loopMutex.RUnlock()
+
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	e.emittedMutex.Lock()
+	e.emitted = append(e.emitted, QueuedEvent{ID: id, Event: event})
+	e.emittedMutex.Unlock()
+
+	select {
+	case wake <- struct{}{}:
+	default:
+		// the loop has already been woken up
+	}
+}
+
+// startBee runs a bee and supervises it. Panicking bees get restarted with an
+// exponential backoff, until they crashed MaxBeeRestarts times in a row. Bees
+// which return from Run on their own are considered stopped.
+func (e *Engine) startBee(bee *BeeInterface) {
+	(*bee).WaitGroup().Add(1)
+	defer (*bee).WaitGroup().Done()
+
+	failures := 0
+	for {
+		(*bee).SetState(BeeRunning, nil)
+
+		started := time.Now()
+		err := e.runBee(bee)
+		if err == nil {
+			(*bee).SetState(BeeStopped, nil)
+			return
+		}
+
+		if time.Since(started) > beeRestartMaxBackoff {
+			// the bee has been running fine for a while
+			failures = 0
+		}
+		failures++
+
+		if failures > MaxBeeRestarts {
+			log.Errorln("Giving up on bee", (*bee).Name(), "after", failures, "crashes in a row:", err)
+			(*bee).SetState(BeeFailed, err)
+			return
+		}
+
+		d := beeRestartBackoff
+		for i := 1; i < failures && d < beeRestartMaxBackoff; i++ {
+			d *= 2
+		}
+		if d > beeRestartMaxBackoff {
+			d = beeRestartMaxBackoff
+		}
+
+		log.Errorln("Bee", (*bee).Name(), "crashed, restarting in", d, ":", err)
+		(*bee).SetState(BeeBackoff, err)
+		if !waitForRestart(bee, d) {
+			return
+		}
+		beeRestartsTotal.WithLabelValues((*bee).Name()).Inc()
+	}
+}
+
+// runBee runs a bee until it returns and turns panics into errors.
+func (e *Engine) runBee(bee *BeeInterface) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			log.Debugf("Fatal bee event: %s %s", e, debug.Stack())
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+
+	events, _ := e.loop()
+	(*bee).Run(events)
+	return nil
+}
+
+// waitForRestart waits before a bee gets restarted. Returns false if the bee
+// got stopped in the meantime.
+func waitForRestart(bee *BeeInterface, d time.Duration) bool {
+	s, ok := (*bee).(stopper)
+	if !ok {
+		time.Sleep(d)
+		return true
+	}
+
+	select {
+	case <-time.After(d):
+		return true
+	case <-s.stopChan():
+		return false
+	}
+}
@@ -0,0 +1,151 @@
+package bees
+
+import (
+	"testing"
+	"time"
+)
+
+type crashingBee struct {
+	Bee
+	crashes int
+	runs    chan int
+}
+
+func (bee *crashingBee) ReloadOptions(options BeeOptions) {
+}
+
+func (bee *crashingBee) Run(eventChan chan Event) {
+	bee.runs <- 1
+	if bee.crashes > 0 {
+		bee.crashes--
+		panic("crashed")
+	}
+
+	<-bee.SigChan
+}
+
+func waitForState(t *testing.T, bee BeeInterface, state BeeState) {
+	for i := 0; i < 200; i++ {
+		if bee.Status().State == state {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Expected bee to be %s, got %s", state, bee.Status().State)
+}
+
+func TestBeeSupervision(t *testing.T) {
+	beeRestartBackoff = time.Millisecond
+	defer func() { beeRestartBackoff = time.Second }()
+
+	cb := &crashingBee{Bee: NewBee("crashing", "crashingbee", "", BeeOptions{}), crashes: 2, runs: make(chan int, 10)}
+	var bee BeeInterface = cb
+
+	bee.Start()
+	go defaultEngine.startBee(&bee)
+	waitForState(t, bee, BeeRunning)
+	for i := 0; i < 3; i++ {
+		<-cb.runs
+	}
+	waitForState(t, bee, BeeRunning)
+
+	status := bee.Status()
+	if status.Restarts != 2 || status.LastError != "crashed" {
+		t.Errorf("Unexpected status after restarts: %+v", status)
+	}
+
+	if !bee.IsRunning() {
+		t.Error("Expected a supervised bee to be running")
+	}
+
+	bee.Stop()
+	if bee.IsRunning() || bee.Status().State != BeeStopped {
+		t.Errorf("Expected bee to be stopped, got %s", bee.Status().State)
+	}
+}
+
+func TestBeeFailsAfterRestarts(t *testing.T) {
+	beeRestartBackoff = time.Millisecond
+	defer func() { beeRestartBackoff = time.Second }()
+
+	cb := &crashingBee{Bee: NewBee("failing", "crashingbee", "", BeeOptions{}), crashes: MaxBeeRestarts + 1, runs: make(chan int, MaxBeeRestarts+2)}
+	var bee BeeInterface = cb
+
+	bee.Start()
+	defaultEngine.startBee(&bee)
+	if bee.Status().State != BeeFailed {
+		t.Errorf("Expected bee to have failed, got %s", bee.Status().State)
+	}
+	if len(cb.runs) != MaxBeeRestarts+1 {
+		t.Errorf("Expected %d runs, got %d", MaxBeeRestarts+1, len(cb.runs))
+	}
+	if bee.IsRunning() {
+		t.Error("Expected a failed bee not to be running anymore")
+	}
+}
+
+func TestStopBeeDuringBackoff(t *testing.T) {
+	beeRestartBackoff = time.Hour
+	defer func() { beeRestartBackoff = time.Second }()
+
+	cb := &crashingBee{Bee: NewBee("backoff", "crashingbee", "", BeeOptions{}), crashes: 1, runs: make(chan int, 10)}
+	var bee BeeInterface = cb
+
+	bee.Start()
+	go defaultEngine.startBee(&bee)
+	waitForState(t, bee, BeeBackoff)
+
+	done := make(chan bool)
+	go func() {
+		bee.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stopping a bee in backoff blocked")
+	}
+}
+
+type exitingBee struct {
+	Bee
+}
+
+func (bee *exitingBee) ReloadOptions(options BeeOptions) {
+}
+
+func (bee *exitingBee) Run(eventChan chan Event) {
+}
+
+func TestBeeExits(t *testing.T) {
+	var bee BeeInterface = &exitingBee{Bee: NewBee("exiting", "exitingbee", "", BeeOptions{})}
+
+	bee.Start()
+	defaultEngine.startBee(&bee)
+	if bee.IsRunning() || bee.Status().State != BeeStopped {
+		t.Errorf("Expected a bee returning from Run to be stopped, got %s", bee.Status().State)
+	}
+}
+
+func TestBeeStateChangesNotDropped(t *testing.T) {
+	e := NewEngine()
+	// pretend the event loop is running, but stuck
+	e.loopDone = make(chan struct{})
+
+	bee := NewBee("flapping", "crashingbee", "", BeeOptions{})
+	bee.setEngine(e)
+	for i := 0; i < 1000; i++ {
+		bee.SetState(BeeDegraded, nil)
+		bee.SetState(BeeRunning, nil)
+	}
+
+	n := 0
+	for _, qe := range e.takeEmitted() {
+		if qe.Event.Name == BeeStateChangedEvent {
+			n++
+		}
+	}
+	if n != 2000 {
+		t.Errorf("Expected 2000 state changes to reach the event loop, got %d", n)
+	}
+}
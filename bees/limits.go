@@ -0,0 +1,316 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ChainLimits controls how often a chain may fire.
+type ChainLimits struct {
+	// Rate is the amount of executions allowed per Per (token bucket)
+	Rate int
+	// Per is the interval Rate refers to, e.g. "1m". Defaults to "1s"
+	Per string
+	// Burst is the maximum amount of executions in a row. Defaults to Rate
+	Burst int
+	// Debounce fires the chain once, after no further event arrived for this
+	// duration, e.g. "30s". The last event wins
+	Debounce string
+	// Throttle fires the chain at most once per window, e.g. "10m"
+	Throttle string
+	// Key is an optional template, e.g. "{{.host}}". All limits are tracked
+	// separately per rendered key
+	Key string
+}
+
+var (
+	// how often a limiter drops the state of keys which went idle
+	limiterSweepInterval = time.Minute
+)
+
+// ChainStats contains execution statistics for a chain.
+type ChainStats struct {
+	Executions uint64
+	Suppressed uint64
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type debouncedRun struct {
+	timer    *time.Timer
+	suppress func()
+}
+
+type chainLimiter struct {
+	sync.Mutex
+
+	stats     ChainStats
+	buckets   map[string]*tokenBucket
+	lastRun   map[string]time.Time
+	debounced map[string]*debouncedRun
+	swept     time.Time
+}
+
+// Validate checks the limits for invalid values.
+func (l *ChainLimits) Validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.Rate < 0 || l.Burst < 0 {
+		return errors.New("Rate limits can't be negative")
+	}
+	for _, d := range []string{l.Per, l.Debounce, l.Throttle} {
+		if len(d) == 0 {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetChainStats returns the execution statistics of a chain.
+func GetChainStats(name string) ChainStats {
+	return defaultEngine.GetChainStats(name)
+}
+
+// GetChainStats returns the engine's statistics for a chain.
+func (e *Engine) GetChainStats(name string) ChainStats {
+	l := e.getLimiter(name)
+
+	l.Lock()
+	defer l.Unlock()
+	return l.stats
+}
+
+func (e *Engine) getLimiter(name string) *chainLimiter {
+	e.limiterMutex.Lock()
+	defer e.limiterMutex.Unlock()
+
+	l, ok := e.limiters[name]
+	if !ok {
+		l = &chainLimiter{
+			buckets:   make(map[string]*tokenBucket),
+			lastRun:   make(map[string]time.Time),
+			debounced: make(map[string]*debouncedRun),
+		}
+		e.limiters[name] = l
+	}
+
+	return l
+}
+
+// dropLimiter discards a chain's limiter. Pending debounced executions get
+// cancelled and recorded as suppressed.
+func (e *Engine) dropLimiter(name string) {
+	e.limiterMutex.Lock()
+	l, ok := e.limiters[name]
+	delete(e.limiters, name)
+	e.limiterMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	l.Lock()
+	var cancelled []*debouncedRun
+	for key, d := range l.debounced {
+		if d.timer.Stop() {
+			cancelled = append(cancelled, d)
+		}
+		delete(l.debounced, key)
+	}
+	l.Unlock()
+
+	for _, d := range cancelled {
+		d.suppress()
+	}
+}
+
+// fireChain executes a chain's actions, unless the chain's limits suppress it.
+// Exactly one of exec and suppress gets called for every event, debounced
+// chains call them once the debounce period is over. Only executions that
+// aren't delayed run on the worker w.
+func (e *Engine) fireChain(c Chain, m map[string]interface{}, exec func(Chain, map[string]interface{}, *worker), suppress func(), w *worker) {
+	l := e.getLimiter(c.Name)
+	if c.Limits == nil {
+		l.Lock()
+		l.stats.Executions++
+		l.Unlock()
+
+		exec(c, m, w)
+		return
+	}
+
+	key := ""
+	if len(c.Limits.Key) > 0 {
+		var err error
+		key, err = renderTemplate(c.Name+"_limitkey", c.Limits.Key, m)
+		if err != nil {
+			log.Errorln("\t\tERROR: Failed to render limit key:", err)
+		}
+	}
+
+	if len(c.Limits.Debounce) > 0 {
+		l.debounce(c, key, m, exec, suppress)
+		return
+	}
+
+	if !l.allow(c.Limits, key) {
+		suppress()
+		return
+	}
+
+	exec(c, m, w)
+}
+
+// allow checks the throttle & rate limits and counts the outcome.
+func (l *chainLimiter) allow(limits *ChainLimits, key string) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	l.sweep(limits, now)
+	if len(limits.Throttle) > 0 {
+		window := parseDuration(limits.Throttle, 0)
+		if last, ok := l.lastRun[key]; ok && now.Sub(last) < window {
+			log.Debugln("\t\tChain throttled")
+			l.stats.Suppressed++
+			return false
+		}
+	}
+
+	if limits.Rate > 0 {
+		burst := float64(limits.Burst)
+		if burst == 0 {
+			burst = float64(limits.Rate)
+		}
+		per := parseDuration(limits.Per, time.Second)
+
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &tokenBucket{tokens: burst, last: now}
+			l.buckets[key] = b
+		}
+		b.tokens += now.Sub(b.last).Seconds() * float64(limits.Rate) / per.Seconds()
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+
+		if b.tokens < 1 {
+			log.Debugln("\t\tChain rate limited")
+			l.stats.Suppressed++
+			return false
+		}
+		b.tokens--
+	}
+
+	if len(limits.Throttle) > 0 {
+		l.lastRun[key] = now
+	}
+	l.stats.Executions++
+	return true
+}
+
+// sweep drops the state of all keys which are back to their initial state:
+// buckets which got refilled completely and runs older than the throttle
+// window. Runs at most once per limiterSweepInterval. The caller must hold
+// the lock.
+func (l *chainLimiter) sweep(limits *ChainLimits, now time.Time) {
+	if now.Sub(l.swept) < limiterSweepInterval {
+		return
+	}
+	l.swept = now
+
+	window := parseDuration(limits.Throttle, 0)
+	for key, last := range l.lastRun {
+		if now.Sub(last) >= window {
+			delete(l.lastRun, key)
+		}
+	}
+
+	if limits.Rate <= 0 {
+		l.buckets = make(map[string]*tokenBucket)
+		return
+	}
+	burst := float64(limits.Burst)
+	if burst == 0 {
+		burst = float64(limits.Rate)
+	}
+	per := parseDuration(limits.Per, time.Second)
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*float64(limits.Rate)/per.Seconds() >= burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// debounce (re-)schedules the chain's execution. Only the last event of a
+// burst gets executed, all earlier ones get suppressed.
+func (l *chainLimiter) debounce(c Chain, key string, m map[string]interface{}, exec func(Chain, map[string]interface{}, *worker), suppress func()) {
+	l.Lock()
+	var superseded *debouncedRun
+	if d, ok := l.debounced[key]; ok && d.timer.Stop() {
+		l.stats.Suppressed++
+		superseded = d
+	}
+
+	d := &debouncedRun{suppress: suppress}
+	d.timer = time.AfterFunc(parseDuration(c.Limits.Debounce, 0), func() {
+		l.Lock()
+		if l.debounced[key] == d {
+			delete(l.debounced, key)
+		}
+		l.Unlock()
+
+		defer func() {
+			if e := recover(); e != nil {
+				log.Printf("Fatal chain event: %s %s", e, debug.Stack())
+			}
+		}()
+
+		if !l.allow(c.Limits, key) {
+			suppress()
+			return
+		}
+
+		log.Debugln("Executing debounced chain:", c.Name, "-", c.Description)
+		exec(c, m, nil)
+	})
+	l.debounced[key] = d
+	l.Unlock()
+
+	if superseded != nil {
+		superseded.suppress()
+	}
+}
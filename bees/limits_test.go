@@ -0,0 +1,188 @@
+package bees
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChainLimits(t *testing.T) {
+	e := NewEngine()
+	var mutex sync.Mutex
+	runs := map[string]int{}
+	suppressed := map[string]int{}
+	exec := func(c Chain, m map[string]interface{}, w *worker) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		runs[c.Name]++
+	}
+
+	cases := []struct {
+		chain    Chain
+		events   int
+		expected int
+	}{
+		{Chain{Name: "unlimited"}, 5, 5},
+		{Chain{Name: "ratelimited", Limits: &ChainLimits{Rate: 2, Per: "1h"}}, 5, 2},
+		{Chain{Name: "throttled", Limits: &ChainLimits{Throttle: "1h"}}, 5, 1},
+		{Chain{Name: "debounced", Limits: &ChainLimits{Debounce: "50ms"}}, 5, 1},
+	}
+	for _, c := range cases {
+		name := c.chain.Name
+		suppress := func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			suppressed[name]++
+		}
+		for i := 0; i < c.events; i++ {
+			e.fireChain(c.chain, map[string]interface{}{}, exec, suppress, nil)
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	for _, c := range cases {
+		mutex.Lock()
+		n, s := runs[c.chain.Name], suppressed[c.chain.Name]
+		mutex.Unlock()
+		if n != c.expected {
+			t.Errorf("Expected chain %s to run %d times, got %d", c.chain.Name, c.expected, n)
+		}
+		if n+s != c.events {
+			t.Errorf("Expected chain %s to suppress %d events, got %d", c.chain.Name, c.events-c.expected, s)
+		}
+
+		stats := e.GetChainStats(c.chain.Name)
+		if stats.Executions != uint64(c.expected) || stats.Suppressed != uint64(c.events-c.expected) {
+			t.Errorf("Unexpected stats for chain %s: %+v", c.chain.Name, stats)
+		}
+	}
+}
+
+func TestChainLimitsKey(t *testing.T) {
+	e := NewEngine()
+	runs := 0
+	exec := func(c Chain, m map[string]interface{}, w *worker) {
+		runs++
+	}
+
+	c := Chain{Name: "keyed", Limits: &ChainLimits{Throttle: "1h", Key: "{{.host}}"}}
+	for _, host := range []string{"a", "b", "a", "c", "b"} {
+		e.fireChain(c, map[string]interface{}{"host": host}, exec, func() {}, nil)
+	}
+	if runs != 3 {
+		t.Errorf("Expected chain to run once per key, got %d runs", runs)
+	}
+}
+
+func TestDebouncedExecutions(t *testing.T) {
+	e := NewEngine()
+	bee := startEngineTestBee(e, "debouncetest")
+	defer e.DeleteBee(e.GetBee("debouncetest"))
+
+	e.SetActions([]Action{{ID: "debounced", Bee: "debouncetest", Name: "echo"}})
+	e.SetChains([]Chain{{
+		Name:    "debounced-executions",
+		Event:   &Event{Bee: "debouncetest", Name: "message"},
+		Actions: []string{"debounced"},
+		Limits:  &ChainLimits{Debounce: "20ms", Throttle: "1h"},
+	}})
+	defer e.SetChains([]Chain{})
+
+	// the second burst gets debounced, but then rejected by the throttle
+	for burst := 0; burst < 2; burst++ {
+		for i := 0; i < 3; i++ {
+			e.execChains(&Event{Bee: "debouncetest", Name: "message"}, nil, nil)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if n := len(bee.recorded()); n != 1 {
+		t.Errorf("Expected the chain to run once, got %d runs", n)
+	}
+	suppressed := 0
+	for _, x := range e.GetExecutions(ExecutionQuery{Chain: "debounced-executions"}) {
+		if x.Status == ExecutionSuppressed {
+			suppressed++
+		}
+	}
+	if suppressed != 5 {
+		t.Errorf("Expected 5 suppressed executions, got %d", suppressed)
+	}
+}
+
+func TestChainLimitsCleanup(t *testing.T) {
+	limiterSweepInterval = 0
+	defer func() { limiterSweepInterval = time.Minute }()
+
+	e := NewEngine()
+	limits := &ChainLimits{Rate: 1000, Throttle: "20ms", Key: "{{.host}}"}
+	c := Chain{Name: "swept", Limits: limits}
+	for _, host := range []string{"a", "b", "c"} {
+		e.fireChain(c, map[string]interface{}{"host": host}, func(Chain, map[string]interface{}, *worker) {}, func() {}, nil)
+	}
+	time.Sleep(30 * time.Millisecond)
+	e.fireChain(c, map[string]interface{}{"host": "d"}, func(Chain, map[string]interface{}, *worker) {}, func() {}, nil)
+
+	l := e.getLimiter("swept")
+	l.Lock()
+	if len(l.lastRun) != 1 || len(l.buckets) != 1 {
+		t.Errorf("Expected the state of idle keys to be dropped, got %d runs and %d buckets", len(l.lastRun), len(l.buckets))
+	}
+	l.Unlock()
+}
+
+func TestDebouncedAck(t *testing.T) {
+	e := NewEngine()
+	bee := startEngineTestBee(e, "debounceacktest")
+	defer e.DeleteBee(e.GetBee("debounceacktest"))
+
+	e.SetActions([]Action{{ID: "debounced", Bee: "debounceacktest", Name: "echo"}})
+	chain := Chain{
+		Name:    "debounced-ack",
+		Event:   &Event{Bee: "debounceacktest", Name: "message"},
+		Actions: []string{"debounced"},
+		Limits:  &ChainLimits{Debounce: "20ms"},
+	}
+	e.SetChains([]Chain{chain})
+	defer e.SetChains([]Chain{})
+
+	var mutex sync.Mutex
+	acked := 0
+	process := func() {
+		ack := &pendingAck{pending: 1, ack: func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			acked++
+		}}
+		e.execChains(&Event{Bee: "debounceacktest", Name: "message"}, ack, nil)
+		ack.done()
+	}
+	count := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return acked
+	}
+
+	process()
+	if count() != 0 {
+		t.Error("Expected the event not to be acknowledged before the debounced run")
+	}
+	bee.waitForActions(1)
+	time.Sleep(10 * time.Millisecond)
+	if count() != 1 {
+		t.Errorf("Expected the event to be acknowledged after the debounced run, got %d acks", count())
+	}
+
+	// editing the chain cancels its pending debounced run
+	process()
+	edited := chain
+	edited.Limits = &ChainLimits{Debounce: "30ms"}
+	e.SetChains([]Chain{edited})
+	if count() != 2 {
+		t.Errorf("Expected the cancelled run's event to be acknowledged, got %d acks", count())
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(bee.recorded()); n != 1 {
+		t.Errorf("Expected the cancelled run not to execute, got %d runs", n)
+	}
+}
@@ -22,9 +22,9 @@
 package bees
 
 import (
-	"sort"
-	"sync"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // LogMessage stores a log message with its timestamp, type and originating Bee
@@ -36,11 +36,6 @@ type LogMessage struct {
 	Timestamp   time.Time
 }
 
-var (
-	logs     = make(map[string][]LogMessage)
-	logMutex sync.RWMutex
-)
-
 // MessageType defines the log level of the log entry we're dealing with
 type MessageType uint
 
@@ -76,28 +71,69 @@ func NewLogMessage(bee string, message string, messageType MessageType) LogMessa
 	}
 }
 
+// SetLogStore replaces the store log messages get kept in.
+func SetLogStore(s LogStore) {
+	defaultEngine.SetLogStore(s)
+}
+
+// SetLogStore replaces the store the engine keeps its log messages in and
+// closes the previous one.
+func (e *Engine) SetLogStore(s LogStore) {
+	e.logMutex.Lock()
+	defer e.logMutex.Unlock()
+
+	if e.logStore != nil && e.logStore != s {
+		if err := e.logStore.Close(); err != nil {
+			log.Errorln("Failed closing log store:", err)
+		}
+	}
+	e.logStore = s
+}
+
 // Log adds a new LogMessage to the log
 func Log(bee string, message string, messageType MessageType) {
-	logMutex.Lock()
-	defer logMutex.Unlock()
+	defaultEngine.Log(bee, message, messageType)
+}
+
+// Log adds a new LogMessage to the engine's log
+func (e *Engine) Log(bee string, message string, messageType MessageType) {
+	l := NewLogMessage(bee, message, messageType)
 
-	logs[bee] = append(logs[bee], NewLogMessage(bee, message, messageType))
+	e.logMutex.RLock()
+	err := e.logStore.Append(l)
+	e.logMutex.RUnlock()
+	if err != nil {
+		log.Errorln("Failed storing log message:", err)
+	}
+
+	logMessagesTotal.WithLabelValues(bee, logLevelName(messageType)).Inc()
+	e.publish(StreamMessage{Type: StreamLog, Log: &l})
 }
 
 // GetLogs returns all logs for a Bee.
 func GetLogs(bee string) []LogMessage {
-	r := []LogMessage{}
-
-	logMutex.RLock()
-	for b, ls := range logs {
-		if len(bee) == 0 || bee == b {
-			for _, l := range ls {
-				r = append(r, l)
-			}
-		}
-	}
-	logMutex.RUnlock()
+	return defaultEngine.GetLogs(bee)
+}
+
+// GetLogs returns all of the engine's logs for a Bee.
+func (e *Engine) GetLogs(bee string) []LogMessage {
+	e.logMutex.RLock()
+	defer e.logMutex.RUnlock()
 
-	sort.Sort(LogSorter(r))
+	r, _, _ := e.logStore.Query(LogQuery{Bee: bee})
 	return r
 }
+
+// QueryLogs returns all log messages matching a query, newest first, and a
+// cursor for the next page of results.
+func QueryLogs(q LogQuery) ([]LogMessage, string, error) {
+	return defaultEngine.QueryLogs(q)
+}
+
+// QueryLogs returns all of the engine's log messages matching a query.
+func (e *Engine) QueryLogs(q LogQuery) ([]LogMessage, string, error) {
+	e.logMutex.RLock()
+	defer e.logMutex.RUnlock()
+
+	return e.logStore.Query(q)
+}
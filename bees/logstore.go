@@ -0,0 +1,394 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLogsPerBee is the amount of log messages kept per bee, unless
+	// configured otherwise
+	DefaultLogsPerBee = 1000
+
+	// amount of segment files kept on disk per bee
+	logSegmentsPerBee = 2
+	// prefix of the directories holding a bee's segment files, followed by
+	// the hex-encoded name of the bee
+	logDirPrefix = "bee-"
+)
+
+// LogQuery describes which log messages to retrieve. Empty fields match
+// everything. Cursor continues a previous query, as returned by LogStore.Query.
+type LogQuery struct {
+	Bee    string
+	Level  *MessageType
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Cursor string
+}
+
+// LogStore is the interface implemented by log storage backends.
+type LogStore interface {
+	// Append stores a log message
+	Append(msg LogMessage) error
+	// Query returns matching log messages, newest first. If there are more
+	// results than the query's limit, a cursor for the next page is returned
+	Query(q LogQuery) ([]LogMessage, string, error)
+	// Close releases all resources held by the store
+	Close() error
+}
+
+// logEntry is a stored log message with its position in the log.
+type logEntry struct {
+	Seq uint64
+	LogMessage
+}
+
+// logRing is a fixed-size ring buffer of log entries.
+type logRing struct {
+	entries []logEntry
+	head    int
+	n       int
+}
+
+func (r *logRing) push(e logEntry) {
+	if r.n < len(r.entries) {
+		r.entries[(r.head+r.n)%len(r.entries)] = e
+		r.n++
+		return
+	}
+
+	r.entries[r.head] = e
+	r.head = (r.head + 1) % len(r.entries)
+}
+
+func (r *logRing) each(f func(e logEntry)) {
+	for i := 0; i < r.n; i++ {
+		f(r.entries[(r.head+i)%len(r.entries)])
+	}
+}
+
+// MemoryLogStore keeps a bounded amount of log messages per bee in memory.
+type MemoryLogStore struct {
+	sync.RWMutex
+
+	perBee int
+	seq    uint64
+	logs   map[string]*logRing
+}
+
+// NewMemoryLogStore returns a new MemoryLogStore, keeping at most perBee log
+// messages for each bee.
+func NewMemoryLogStore(perBee int) *MemoryLogStore {
+	if perBee < 1 {
+		perBee = DefaultLogsPerBee
+	}
+
+	return &MemoryLogStore{
+		perBee: perBee,
+		logs:   make(map[string]*logRing),
+	}
+}
+
+// Append stores a log message
+func (s *MemoryLogStore) Append(msg LogMessage) error {
+	s.append(msg)
+	return nil
+}
+
+func (s *MemoryLogStore) append(msg LogMessage) logEntry {
+	s.Lock()
+	defer s.Unlock()
+
+	s.seq++
+	e := logEntry{Seq: s.seq, LogMessage: msg}
+	s.add(e)
+
+	return e
+}
+
+func (s *MemoryLogStore) add(e logEntry) {
+	r, ok := s.logs[e.Bee]
+	if !ok {
+		r = &logRing{entries: make([]logEntry, s.perBee)}
+		s.logs[e.Bee] = r
+	}
+	r.push(e)
+
+	if e.Seq > s.seq {
+		s.seq = e.Seq
+	}
+}
+
+// Query returns matching log messages, newest first
+func (s *MemoryLogStore) Query(q LogQuery) ([]LogMessage, string, error) {
+	var before uint64
+	if len(q.Cursor) > 0 {
+		var err error
+		before, err = strconv.ParseUint(q.Cursor, 10, 64)
+		if err != nil {
+			return nil, "", errors.New("Invalid cursor")
+		}
+	}
+
+	es := []logEntry{}
+	match := func(e logEntry) {
+		if before > 0 && e.Seq >= before {
+			return
+		}
+		if q.Level != nil && logSeverity(MessageType(e.MessageType)) < logSeverity(*q.Level) {
+			return
+		}
+		if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+			return
+		}
+		if !q.Until.IsZero() && e.Timestamp.After(q.Until) {
+			return
+		}
+		es = append(es, e)
+	}
+
+	s.RLock()
+	for b, r := range s.logs {
+		if len(q.Bee) == 0 || q.Bee == b {
+			r.each(match)
+		}
+	}
+	s.RUnlock()
+
+	sort.Slice(es, func(i, j int) bool { return es[i].Seq > es[j].Seq })
+
+	cursor := ""
+	if q.Limit > 0 && len(es) > q.Limit {
+		es = es[:q.Limit]
+		cursor = strconv.FormatUint(es[len(es)-1].Seq, 10)
+	}
+
+	r := make([]LogMessage, 0, len(es))
+	for _, e := range es {
+		r = append(r, e.LogMessage)
+	}
+
+	return r, cursor, nil
+}
+
+// Close releases all resources held by the store
+func (s *MemoryLogStore) Close() error {
+	return nil
+}
+
+// DiskLogStore persists log messages in rotated segment files on disk, so
+// they survive restarts. Every bee gets its own directory of segments, each
+// holding as many messages as are kept per bee, so a chatty bee can't push
+// the logs of the others off the disk. Queries are served from memory,
+// bounded per bee just like the MemoryLogStore.
+type DiskLogStore struct {
+	sync.Mutex
+	mem *MemoryLogStore
+
+	path string
+	bees map[string]*logSegments
+}
+
+// logSegments is the segment file a bee's log messages currently get
+// written to.
+type logSegments struct {
+	path    string
+	file    *os.File
+	segment int
+	records int
+}
+
+// NewDiskLogStore opens (or creates) a DiskLogStore in the given directory
+// and restores the most recent log messages.
+func NewDiskLogStore(path string, perBee int) (*DiskLogStore, error) {
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &DiskLogStore{
+		mem:  NewMemoryLogStore(perBee),
+		path: path,
+		bees: make(map[string]*logSegments),
+	}
+
+	dirs, err := filepath.Glob(filepath.Join(path, logDirPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		b, err := hex.DecodeString(strings.TrimPrefix(filepath.Base(dir), logDirPrefix))
+		if err != nil {
+			continue
+		}
+
+		ls := &logSegments{path: dir}
+		segments, err := segmentFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, seg := range segments {
+			err = s.replay(ls, seg)
+			if err != nil {
+				return nil, err
+			}
+			ls.segment = seg
+		}
+		s.bees[string(b)] = ls
+	}
+
+	return s, nil
+}
+
+// Append stores a log message
+func (s *DiskLogStore) Append(msg LogMessage) error {
+	s.Lock()
+	defer s.Unlock()
+
+	e := s.mem.append(msg)
+
+	ls, ok := s.bees[msg.Bee]
+	if !ok {
+		ls = &logSegments{path: filepath.Join(s.path, logDirPrefix+hex.EncodeToString([]byte(msg.Bee)))}
+		s.bees[msg.Bee] = ls
+	}
+	if ls.file == nil || ls.records >= s.mem.perBee {
+		err := s.rotate(ls)
+		if err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = ls.file.Write(append(b, '\n'))
+	if err != nil {
+		return err
+	}
+	ls.records++
+
+	return nil
+}
+
+// Query returns matching log messages, newest first
+func (s *DiskLogStore) Query(q LogQuery) ([]LogMessage, string, error) {
+	return s.mem.Query(q)
+}
+
+// Close closes the segment files the store currently writes to.
+func (s *DiskLogStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	var err error
+	for _, ls := range s.bees {
+		if ls.file == nil {
+			continue
+		}
+		if cerr := ls.file.Close(); cerr != nil {
+			err = cerr
+		}
+		ls.file = nil
+	}
+
+	return err
+}
+
+// rotate starts a new segment file for a bee and removes the bee's oldest
+// segments. The previous segment holds a full set of log messages, so
+// together with the new one there are always enough left to restore.
+func (s *DiskLogStore) rotate(ls *logSegments) error {
+	if ls.file != nil {
+		ls.file.Close()
+	}
+
+	err := os.MkdirAll(ls.path, 0700)
+	if err != nil {
+		return err
+	}
+
+	// a reopened store appends to the bee's last segment until it's full
+	if ls.segment == 0 || ls.records >= s.mem.perBee {
+		ls.segment++
+		ls.records = 0
+	}
+	f, err := os.OpenFile(s.segmentPath(ls, ls.segment), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	ls.file = f
+
+	segments, err := segmentFiles(ls.path)
+	if err != nil {
+		return err
+	}
+	for len(segments) > logSegmentsPerBee {
+		os.Remove(s.segmentPath(ls, segments[0]))
+		segments = segments[1:]
+	}
+
+	return nil
+}
+
+// replay restores log messages from one of a bee's segment files.
+func (s *DiskLogStore) replay(ls *logSegments, seg int) error {
+	f, err := os.Open(s.segmentPath(ls, seg))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ls.records = 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e logEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// a partially written record from a crash, skip it
+			continue
+		}
+		s.mem.add(e)
+		ls.records++
+	}
+
+	return scanner.Err()
+}
+
+func (s *DiskLogStore) segmentPath(ls *logSegments, seg int) string {
+	return filepath.Join(ls.path, fmt.Sprintf("%020d.log", seg))
+}
@@ -0,0 +1,141 @@
+package bees
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestMemoryLogStore(t *testing.T) {
+	s := NewMemoryLogStore(10)
+	for i := 0; i < 25; i++ {
+		s.Append(NewLogMessage("first", fmt.Sprintf("%d", i), LogInfo))
+	}
+	s.Append(NewLogMessage("second", "error", LogError))
+
+	logs, _, _ := s.Query(LogQuery{Bee: "first"})
+	if len(logs) != 10 {
+		t.Fatalf("Expected 10 logs to be kept, got %d", len(logs))
+	}
+	if logs[0].Message != "24" || logs[9].Message != "15" {
+		t.Errorf("Expected the newest logs first, got %s to %s", logs[0].Message, logs[9].Message)
+	}
+
+	level := LogError
+	logs, _, _ = s.Query(LogQuery{Level: &level})
+	if len(logs) != 1 || logs[0].Bee != "second" {
+		t.Errorf("Expected only the error log, got %+v", logs)
+	}
+
+	// page through all logs
+	q := LogQuery{Limit: 4}
+	seen := 0
+	for i := 0; i < 10; i++ {
+		logs, cursor, err := s.Query(q)
+		if err != nil {
+			t.Fatalf("Error querying logs: %v", err)
+		}
+		seen += len(logs)
+		if cursor == "" {
+			break
+		}
+		q.Cursor = cursor
+	}
+	if seen != 11 {
+		t.Errorf("Expected to page through 11 logs, got %d", seen)
+	}
+}
+
+func TestDiskLogStore(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "beehivetest")
+	if err != nil {
+		t.Fatal("Could not create temp directory")
+	}
+	defer os.RemoveAll(tmpdir)
+
+	s, err := NewDiskLogStore(tmpdir, 5)
+	if err != nil {
+		t.Fatalf("Error opening log store: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		s.Append(NewLogMessage("disk", fmt.Sprintf("%d", i), LogInfo))
+	}
+	s.Close()
+
+	s, err = NewDiskLogStore(tmpdir, 5)
+	if err != nil {
+		t.Fatalf("Error re-opening log store: %v", err)
+	}
+	defer s.Close()
+
+	s.Append(NewLogMessage("disk", "8", LogInfo))
+	logs, _, _ := s.Query(LogQuery{})
+	if len(logs) != 5 {
+		t.Fatalf("Expected 5 restored logs, got %d", len(logs))
+	}
+	if logs[0].Message != "8" || logs[4].Message != "4" {
+		t.Errorf("Unexpected logs after restart: %s to %s", logs[0].Message, logs[4].Message)
+	}
+}
+
+func TestDiskLogStorePerBee(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "beehivetest")
+	if err != nil {
+		t.Fatal("Could not create temp directory")
+	}
+	defer os.RemoveAll(tmpdir)
+
+	s, err := NewDiskLogStore(tmpdir, 5)
+	if err != nil {
+		t.Fatalf("Error opening log store: %v", err)
+	}
+	s.Append(NewLogMessage("quiet", "hello", LogInfo))
+	for i := 0; i < 100; i++ {
+		s.Append(NewLogMessage("chatty", fmt.Sprintf("%d", i), LogInfo))
+	}
+	s.Close()
+
+	s, err = NewDiskLogStore(tmpdir, 5)
+	if err != nil {
+		t.Fatalf("Error re-opening log store: %v", err)
+	}
+	defer s.Close()
+
+	if logs, _, _ := s.Query(LogQuery{Bee: "quiet"}); len(logs) != 1 {
+		t.Errorf("Expected the quiet bee's log to survive, got %d logs", len(logs))
+	}
+	if logs, _, _ := s.Query(LogQuery{Bee: "chatty"}); len(logs) != 5 || logs[0].Message != "99" {
+		t.Errorf("Expected the chatty bee's latest 5 logs, got %+v", logs)
+	}
+	segments, _ := segmentFiles(s.bees["chatty"].path)
+	if len(segments) != logSegmentsPerBee {
+		t.Errorf("Expected %d segments for the chatty bee, got %d", logSegmentsPerBee, len(segments))
+	}
+}
+
+type closingLogStore struct {
+	*MemoryLogStore
+	closed bool
+}
+
+func (s *closingLogStore) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestSetLogStore(t *testing.T) {
+	e := NewEngine()
+	old := &closingLogStore{MemoryLogStore: NewMemoryLogStore(5)}
+	e.SetLogStore(old)
+	e.Log("swapped", "old", LogInfo)
+
+	e.SetLogStore(NewMemoryLogStore(5))
+	if !old.closed {
+		t.Error("Expected the previous log store to be closed")
+	}
+	e.Log("swapped", "new", LogInfo)
+	if logs := e.GetLogs("swapped"); len(logs) != 1 || logs[0].Message != "new" {
+		t.Errorf("Expected logs to go to the new store, got %+v", logs)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	metrics = prometheus.NewRegistry()
+
+	eventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "beehive",
+			Name:      "events_total",
+			Help:      "Events received, by bee and event name",
+		},
+		[]string{"bee", "event"},
+	)
+	lastEvent = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "beehive",
+			Name:      "last_event_timestamp_seconds",
+			Help:      "Time the last event of a bee was received",
+		},
+		[]string{"bee"},
+	)
+	chainExecutionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "beehive",
+			Name:      "chain_executions_total",
+			Help:      "Chain executions, by chain and resulting status",
+		},
+		[]string{"chain", "status"},
+	)
+	filterEvaluationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "beehive",
+			Name:      "filter_evaluations_total",
+			Help:      "Filter evaluations, by chain and result (passed, failed or error)",
+		},
+		[]string{"chain", "result"},
+	)
+	actionInvocationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "beehive",
+			Name:      "action_invocations_total",
+			Help:      "Action invocations, including retries",
+		},
+		[]string{"bee", "action"},
+	)
+	actionErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "beehive",
+			Name:      "action_errors_total",
+			Help:      "Failed action invocations",
+		},
+		[]string{"bee", "action"},
+	)
+	actionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "beehive",
+			Name:      "action_duration_seconds",
+			Help:      "Latency of action invocations",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 9),
+		},
+		[]string{"bee", "action"},
+	)
+	beeRestartsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "beehive",
+			Name:      "bee_restarts_total",
+			Help:      "Bee restarts after a panic",
+		},
+		[]string{"bee"},
+	)
+	logMessagesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "beehive",
+			Name:      "log_messages_total",
+			Help:      "Log messages, by bee and level",
+		},
+		[]string{"bee", "level"},
+	)
+	queueDepth = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "beehive",
+			Name:      "queue_depth",
+			Help:      "Events in the persistent queue that haven't been handled yet",
+		},
+		func() float64 {
+			if defaultEngine.queue == nil {
+				return 0
+			}
+			return float64(defaultEngine.queue.Len())
+		},
+	)
+	workQueueDepth = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "beehive",
+			Name:      "work_queue_depth",
+			Help:      "Events waiting for a worker",
+		},
+		func() float64 {
+			return float64(defaultEngine.pool().depth())
+		},
+	)
+)
+
+// Metrics returns the registry holding all of the engine's metrics.
+func Metrics() *prometheus.Registry {
+	return metrics
+}
+
+func init() {
+	metrics.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		eventsTotal,
+		lastEvent,
+		chainExecutionsTotal,
+		filterEvaluationsTotal,
+		actionInvocationsTotal,
+		actionErrorsTotal,
+		actionDuration,
+		beeRestartsTotal,
+		logMessagesTotal,
+		queueDepth,
+		workQueueDepth,
+	)
+}
@@ -0,0 +1,32 @@
+package bees
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	// metrics are collected process-wide, so only compare deltas
+	e := NewEngine()
+	logged := logMessagesTotal.WithLabelValues("metricstest", "error")
+	before := testutil.ToFloat64(logged)
+	e.Log("metricstest", "first", LogError)
+	e.Log("metricstest", "second", LogError)
+
+	if v := testutil.ToFloat64(logged) - before; v != 2 {
+		t.Errorf("Expected 2 logged errors, got %f", v)
+	}
+
+	completed := chainExecutionsTotal.WithLabelValues("metricstest", ExecutionCompleted)
+	before = testutil.ToFloat64(completed)
+	x := e.newExecution(Chain{Name: "metricstest"}, Event{})
+	x.finish(ExecutionCompleted)
+	if v := testutil.ToFloat64(completed) - before; v != 1 {
+		t.Errorf("Expected 1 completed execution, got %f", v)
+	}
+
+	if _, err := Metrics().Gather(); err != nil {
+		t.Errorf("Error gathering metrics: %v", err)
+	}
+}
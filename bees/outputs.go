@@ -0,0 +1,78 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ChainEventPrefix prefixes the chain name in the Bee field of events
+	// emitted by chains, e.g. "chain:alerts"
+	ChainEventPrefix = "chain:"
+	// MaxEventHops limits how many chains in a row may emit events, which
+	// breaks loops of chains triggering each other
+	MaxEventHops = 10
+)
+
+// ChainOutput is a synthetic event a chain emits after all of its actions
+// succeeded. Other chains can react on it like on any bee's event.
+type ChainOutput struct {
+	Name string
+	// Options are the event's placeholders. String values are templates,
+	// rendered against the chain's data
+	Options Placeholders
+}
+
+// IsChainEvent returns true if the event was emitted by a chain.
+func IsChainEvent(event *Event) bool {
+	return strings.HasPrefix(event.Bee, ChainEventPrefix)
+}
+
+// emitChainOutputs renders a chain's outputs and hands them to the event loop.
+// Events that exceed MaxEventHops get dropped.
+func (e *Engine) emitChainOutputs(c Chain, m map[string]interface{}, x *Execution) {
+	for _, out := range c.Outputs {
+		event := Event{
+			Bee:  ChainEventPrefix + c.Name,
+			Name: out.Name,
+			Hops: x.Event.Hops + 1,
+		}
+		if event.Hops > MaxEventHops {
+			log.Errorf("Chain %s: dropping event %s after %d hops, chains are triggering each other in a loop",
+				c.Name, out.Name, x.Event.Hops)
+			continue
+		}
+
+		var err error
+		event.Options, err = renderPlaceholders(event.Bee+"_"+event.Name, out.Options, m)
+		if err != nil {
+			log.Errorln("\t\tERROR: Failed to render chain output:", err)
+			continue
+		}
+
+		log.Debugln("\t\tEmitting event:", event.Bee, "/", event.Name)
+		e.emitEvent(event)
+	}
+}
@@ -0,0 +1,56 @@
+package bees
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChainOutputs(t *testing.T) {
+	e := NewEngine()
+	bee := startEngineTestBee(e, "outputtest")
+	defer e.DeleteBee(e.GetBee("outputtest"))
+
+	e.SetActions([]Action{{
+		ID:      "escalated",
+		Bee:     "outputtest",
+		Name:    "echo",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "{{.match.bee}}: {{.summary}}"}},
+	}})
+	e.SetChains([]Chain{
+		{
+			Name:    "alerts",
+			Event:   &Event{Bee: "outputtest", Name: "message"},
+			Outputs: []ChainOutput{{Name: "escalate", Options: Placeholders{{Name: "summary", Value: "{{.text}}!"}}}},
+		},
+		{
+			Name:    "escalation",
+			Event:   &Event{Bee: ChainEventPrefix + "alerts", Name: "escalate"},
+			Actions: []string{"escalated"},
+		},
+		{
+			Name:    "loop",
+			Event:   &Event{Bee: ChainEventPrefix + "loop", Name: "again"},
+			Outputs: []ChainOutput{{Name: "again"}},
+		},
+	})
+	defer e.SetChains([]Chain{})
+
+	e.startEventLoop()
+	defer e.stopEventLoop()
+
+	events, _ := e.loop()
+	events <- Event{Bee: "outputtest", Name: "message", Options: Placeholders{{Name: "text", Type: "string", Value: "disk full"}}}
+	events <- Event{Bee: ChainEventPrefix + "loop", Name: "again"}
+
+	for i := 0; i < 100 && len(e.GetExecutions(ExecutionQuery{Chain: "loop"})) <= MaxEventHops; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if len(bee.recorded()) != 1 || bee.recorded()[0].Options.Value("text") != "chain:alerts: disk full!" {
+		t.Errorf("Expected the emitted event to trigger the escalation chain, got %+v", bee.recorded())
+	}
+	if n := len(e.GetExecutions(ExecutionQuery{Chain: "loop"})); n != MaxEventHops+1 {
+		t.Errorf("Expected the loop to be broken after %d hops, got %d executions", MaxEventHops, n)
+	}
+}
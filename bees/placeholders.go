@@ -22,6 +22,7 @@
 package bees
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
@@ -81,6 +82,24 @@ func (ph Placeholders) Bind(name string, dst interface{}) error {
 	return ConvertValue(v, dst)
 }
 
+// Normalize converts json.Number values, as decoded from API requests, to
+// int64 or float64 values.
+func (ph Placeholders) Normalize() Placeholders {
+	r := Placeholders{}
+	for _, p := range ph {
+		if n, ok := p.Value.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				p.Value = i
+			} else if f, err := n.Float64(); err == nil {
+				p.Value = f
+			}
+		}
+		r = append(r, p)
+	}
+
+	return r
+}
+
 // ConvertValue tries to convert v to dst.
 func ConvertValue(v interface{}, dst interface{}) error {
 	switch d := dst.(type) {
@@ -99,7 +118,7 @@ func ConvertValue(v interface{}, dst interface{}) error {
 		case int:
 			*d = strconv.FormatInt(int64(vt), 10)
 		default:
-			panic(fmt.Sprintf("Unhandled type %+v for string conversion", reflect.TypeOf(vt)))
+			return fmt.Errorf("Unhandled type %+v for string conversion", reflect.TypeOf(vt))
 		}
 
 	case *[]string:
@@ -107,14 +126,18 @@ func ConvertValue(v interface{}, dst interface{}) error {
 		case []interface{}:
 			*d = []string{}
 			for _, v := range vt {
-				*d = append(*d, v.(string))
+				s, ok := v.(string)
+				if !ok {
+					return fmt.Errorf("Unhandled type %+v for []string conversion", reflect.TypeOf(v))
+				}
+				*d = append(*d, s)
 			}
 		case []string:
 			*d = vt
 		case string:
 			*d = strings.Split(vt, ",")
 		default:
-			panic(fmt.Sprintf("Unhandled type %+v for []string conversion", reflect.TypeOf(vt)))
+			return fmt.Errorf("Unhandled type %+v for []string conversion", reflect.TypeOf(vt))
 		}
 
 	case *bool:
@@ -139,7 +162,7 @@ func ConvertValue(v interface{}, dst interface{}) error {
 		case float64:
 			*d = vt > 0
 		default:
-			panic(fmt.Sprintf("Unhandled type %+v for bool conversion", reflect.TypeOf(vt)))
+			return fmt.Errorf("Unhandled type %+v for bool conversion", reflect.TypeOf(vt))
 		}
 
 	case *float64:
@@ -169,10 +192,13 @@ func ConvertValue(v interface{}, dst interface{}) error {
 		case float32:
 			*d = float64(vt)
 		case string:
-			x, _ := strconv.ParseFloat(vt, 64)
-			*d = float64(x)
+			x, err := strconv.ParseFloat(vt, 64)
+			if err != nil {
+				return err
+			}
+			*d = x
 		default:
-			panic(fmt.Sprintf("Unhandled type %+v for float64 conversion", reflect.TypeOf(vt)))
+			return fmt.Errorf("Unhandled type %+v for float64 conversion", reflect.TypeOf(vt))
 		}
 
 	case *int:
@@ -202,9 +228,13 @@ func ConvertValue(v interface{}, dst interface{}) error {
 		case float32:
 			*d = int(vt)
 		case string:
-			*d, _ = strconv.Atoi(vt)
+			x, err := strconv.Atoi(vt)
+			if err != nil {
+				return err
+			}
+			*d = x
 		default:
-			panic(fmt.Sprintf("Unhandled type %+v for int conversion", reflect.TypeOf(vt)))
+			return fmt.Errorf("Unhandled type %+v for int conversion", reflect.TypeOf(vt))
 		}
 
 	case *time.Time:
@@ -215,20 +245,30 @@ func ConvertValue(v interface{}, dst interface{}) error {
 			*d = time.Unix(int64(vt), 0)
 		case int64:
 			*d = time.Unix(vt, 0)
+		case string:
+			x, err := time.Parse(time.RFC3339Nano, vt)
+			if err != nil {
+				return err
+			}
+			*d = x
 		default:
-			panic(fmt.Sprintf("Unhandled type %+v for time.Time conversion", reflect.TypeOf(vt)))
+			return fmt.Errorf("Unhandled type %+v for time.Time conversion", reflect.TypeOf(vt))
 		}
 
 	case *url.Values:
 		switch vt := v.(type) {
 		case string:
-			*d, _ = url.ParseQuery(vt)
+			x, err := url.ParseQuery(vt)
+			if err != nil {
+				return err
+			}
+			*d = x
 		default:
-			panic(fmt.Sprintf("Unhandled type %+v for url.Values conversion", reflect.TypeOf(vt)))
+			return fmt.Errorf("Unhandled type %+v for url.Values conversion", reflect.TypeOf(vt))
 		}
 
 	default:
-		panic(fmt.Sprintf("Unhandled dst type %+v", reflect.TypeOf(dst)))
+		return fmt.Errorf("Unhandled dst type %+v", reflect.TypeOf(dst))
 	}
 
 	return nil
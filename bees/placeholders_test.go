@@ -0,0 +1,25 @@
+package bees
+
+import (
+	"testing"
+)
+
+func TestConvertValueErrors(t *testing.T) {
+	var i int
+	if err := ConvertValue("12", &i); err != nil || i != 12 {
+		t.Errorf("Expected 12, got %d (%v)", i, err)
+	}
+	if err := ConvertValue("twelve", &i); err == nil {
+		t.Error("Expected an error converting an invalid int")
+	}
+
+	var s []string
+	if err := ConvertValue([]interface{}{"a", 1}, &s); err == nil {
+		t.Error("Expected an error converting a mixed slice")
+	}
+
+	var u uint8
+	if err := ConvertValue(1, &u); err == nil {
+		t.Error("Expected an error for an unhandled destination type")
+	}
+}
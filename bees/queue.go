@@ -0,0 +1,374 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	queueOpPush = "push"
+	queueOpAck  = "ack"
+
+	// maximum amount of records written to a single segment file
+	queueSegmentSize = 1000
+)
+
+// QueuedEvent is an Event that has been persisted in the DiskQueue.
+type QueuedEvent struct {
+	ID    string
+	Event Event
+}
+
+type queueEntry struct {
+	QueuedEvent
+	segment int
+	seq     uint64
+}
+
+type queueRecord struct {
+	Op    string
+	ID    string
+	Event *Event `json:",omitempty"`
+}
+
+// DiskQueue is a durable, append-only event queue. Events stay in the queue
+// until they got acknowledged, which gives us at-least-once delivery across
+// crashes and restarts.
+type DiskQueue struct {
+	sync.Mutex
+
+	path    string
+	file    *os.File
+	segment int
+	records int
+
+	seq      uint64
+	pending  map[string]queueEntry
+	counts   map[int]int // segment -> amount of unacknowledged events
+	restored []QueuedEvent
+}
+
+// NewDiskQueue opens (or creates) a DiskQueue in the given directory and
+// restores all events that haven't been acknowledged yet.
+func NewDiskQueue(path string) (*DiskQueue, error) {
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &DiskQueue{
+		path:    path,
+		pending: make(map[string]queueEntry),
+		counts:  make(map[int]int),
+	}
+
+	segments, err := q.segmentFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range segments {
+		err = q.replay(seg)
+		if err != nil {
+			return nil, err
+		}
+		q.segment = seg
+	}
+
+	q.restored = q.sortedPending()
+	err = q.rotate()
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// SetEventQueue enables persisting incoming events in q. Passing nil disables
+// the persistent queue.
+func SetEventQueue(q *DiskQueue) {
+	defaultEngine.SetEventQueue(q)
+}
+
+// SetEventQueue sets the persistent queue for the engine's events.
+func (e *Engine) SetEventQueue(q *DiskQueue) {
+	e.queue = q
+}
+
+// Push persists an event and returns its queue ID.
+func (q *DiskQueue) Push(event Event) (string, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	qe := QueuedEvent{
+		ID:    UUID(),
+		Event: event,
+	}
+	err := q.write(queueRecord{Op: queueOpPush, ID: qe.ID, Event: &event}, true)
+	if err != nil {
+		return "", err
+	}
+
+	q.add(qe, q.segment)
+
+	return qe.ID, nil
+}
+
+// Ack marks an event as completely handled. It won't be redelivered.
+func (q *DiskQueue) Ack(id string) error {
+	q.Lock()
+	defer q.Unlock()
+
+	e, ok := q.pending[id]
+	if !ok {
+		return nil
+	}
+
+	err := q.write(queueRecord{Op: queueOpAck, ID: id}, false)
+	if err != nil {
+		return err
+	}
+
+	q.remove(e)
+	q.compact()
+
+	return nil
+}
+
+// Pending returns all events that haven't been acknowledged yet, in the order
+// they were pushed.
+func (q *DiskQueue) Pending() []QueuedEvent {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.sortedPending()
+}
+
+// Restored returns the unacknowledged events that were found on disk when the
+// queue got opened. It only returns them once, so they get redelivered a
+// single time even if the bees get restarted.
+func (q *DiskQueue) Restored() []QueuedEvent {
+	q.Lock()
+	defer q.Unlock()
+
+	r := q.restored
+	q.restored = nil
+	return r
+}
+
+func (q *DiskQueue) sortedPending() []QueuedEvent {
+	es := []queueEntry{}
+	for _, e := range q.pending {
+		es = append(es, e)
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].seq < es[j].seq })
+
+	r := []QueuedEvent{}
+	for _, e := range es {
+		r = append(r, e.QueuedEvent)
+	}
+
+	return r
+}
+
+// Len returns the amount of unacknowledged events.
+func (q *DiskQueue) Len() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.pending)
+}
+
+// Close closes the queue's current segment file.
+func (q *DiskQueue) Close() error {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.file.Close()
+}
+
+func (q *DiskQueue) write(rec queueRecord, sync bool) error {
+	if q.records >= queueSegmentSize {
+		err := q.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = q.file.Write(append(b, '\n'))
+	if err != nil {
+		return err
+	}
+	q.records++
+
+	if sync {
+		return q.file.Sync()
+	}
+	return nil
+}
+
+// rotate starts a new segment file.
+func (q *DiskQueue) rotate() error {
+	if q.file != nil {
+		q.file.Close()
+	}
+
+	q.segment++
+	f, err := os.OpenFile(q.segmentPath(q.segment), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	q.file = f
+	q.records = 0
+	q.counts[q.segment] = 0
+	q.compact()
+
+	return nil
+}
+
+// compact removes the oldest segments as long as they don't contain any
+// unacknowledged events. Segments can only be dropped in order, as they might
+// hold acknowledgements for events stored in even older segments.
+func (q *DiskQueue) compact() {
+	segs := []int{}
+	for seg := range q.counts {
+		segs = append(segs, seg)
+	}
+	sort.Ints(segs)
+
+	for _, seg := range segs {
+		if seg == q.segment || q.counts[seg] > 0 {
+			break
+		}
+
+		os.Remove(q.segmentPath(seg))
+		delete(q.counts, seg)
+	}
+}
+
+// replay restores the queue's state from a segment file.
+func (q *DiskQueue) replay(seg int) error {
+	f, err := os.Open(q.segmentPath(seg))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, ok := q.counts[seg]; !ok {
+		q.counts[seg] = 0
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec queueRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// a partially written record from a crash, skip it
+			continue
+		}
+
+		switch rec.Op {
+		case queueOpPush:
+			if rec.Event == nil {
+				continue
+			}
+			restorePlaceholderTypes(rec.Event.Options)
+			q.add(QueuedEvent{ID: rec.ID, Event: *rec.Event}, seg)
+
+		case queueOpAck:
+			if e, ok := q.pending[rec.ID]; ok {
+				q.remove(e)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// restorePlaceholderTypes converts decoded JSON values, like float64 numbers
+// and timestamp strings, back to the Go types of their placeholder types.
+// Values which can't be converted are kept as they were decoded.
+func restorePlaceholderTypes(phs Placeholders) {
+	for i, ph := range phs {
+		if v, err := convertPlaceholder(ph.Value, ph.Type); err == nil {
+			phs[i].Value = v
+		}
+	}
+}
+
+func (q *DiskQueue) add(qe QueuedEvent, seg int) {
+	q.seq++
+	q.pending[qe.ID] = queueEntry{
+		QueuedEvent: qe,
+		segment:     seg,
+		seq:         q.seq,
+	}
+	q.counts[seg]++
+}
+
+func (q *DiskQueue) remove(e queueEntry) {
+	delete(q.pending, e.ID)
+	q.counts[e.segment]--
+}
+
+func (q *DiskQueue) segmentPath(seg int) string {
+	return filepath.Join(q.path, fmt.Sprintf("%020d.log", seg))
+}
+
+// segmentFiles returns the numbers of all segment files on disk, in order.
+func (q *DiskQueue) segmentFiles() ([]int, error) {
+	return segmentFiles(q.path)
+}
+
+// segmentFiles returns the numbers of all segment files in a directory, in
+// order.
+func segmentFiles(path string) ([]int, error) {
+	matches, err := filepath.Glob(filepath.Join(path, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	r := []int{}
+	for _, m := range matches {
+		var seg int
+		_, err := fmt.Sscanf(strings.TrimSuffix(filepath.Base(m), ".log"), "%d", &seg)
+		if err != nil {
+			continue
+		}
+		r = append(r, seg)
+	}
+	sort.Ints(r)
+
+	return r, nil
+}
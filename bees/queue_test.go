@@ -0,0 +1,161 @@
+package bees
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiskQueueRedelivery(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "beehivetest")
+	if err != nil {
+		t.Fatal("Could not create temp directory")
+	}
+	defer os.RemoveAll(tmpdir)
+
+	q, err := NewDiskQueue(tmpdir)
+	if err != nil {
+		t.Fatalf("Error opening queue: %v", err)
+	}
+
+	ids := []string{}
+	for _, name := range []string{"first", "second", "third"} {
+		id, err := q.Push(Event{Bee: "testbee", Name: name})
+		if err != nil {
+			t.Fatalf("Error pushing event: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := q.Ack(ids[1]); err != nil {
+		t.Fatalf("Error acknowledging event: %v", err)
+	}
+	q.Close()
+
+	q, err = NewDiskQueue(tmpdir)
+	if err != nil {
+		t.Fatalf("Error re-opening queue: %v", err)
+	}
+	defer q.Close()
+
+	r := q.Restored()
+	if len(r) != 2 {
+		t.Fatalf("Expected 2 restored events, got %d", len(r))
+	}
+	if r[0].Event.Name != "first" || r[1].Event.Name != "third" {
+		t.Errorf("Restored events in wrong order: %+v", r)
+	}
+	if len(q.Restored()) != 0 {
+		t.Error("Restored events should only be returned once")
+	}
+}
+
+func TestDiskQueueCompaction(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "beehivetest")
+	if err != nil {
+		t.Fatal("Could not create temp directory")
+	}
+	defer os.RemoveAll(tmpdir)
+
+	q, err := NewDiskQueue(tmpdir)
+	if err != nil {
+		t.Fatalf("Error opening queue: %v", err)
+	}
+
+	// keep the very first event pending, so no segment can be dropped
+	first, _ := q.Push(Event{Bee: "testbee", Name: "pending"})
+	for i := 0; i < queueSegmentSize*2; i++ {
+		id, err := q.Push(Event{Bee: "testbee", Name: "acked"})
+		if err != nil {
+			t.Fatalf("Error pushing event: %v", err)
+		}
+		q.Ack(id)
+	}
+
+	segs, _ := q.segmentFiles()
+	if len(segs) < 4 {
+		t.Errorf("Expected segments to be kept while the oldest is pending, got %d", len(segs))
+	}
+
+	q.Ack(first)
+	segs, _ = q.segmentFiles()
+	if len(segs) != 1 {
+		t.Errorf("Expected all but the current segment to be removed, got %d", len(segs))
+	}
+	if q.Len() != 0 {
+		t.Errorf("Expected an empty queue, got %d pending events", q.Len())
+	}
+	q.Close()
+}
+
+func TestDiskQueueRestoresTypes(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "beehivetest")
+	if err != nil {
+		t.Fatal("Could not create temp directory")
+	}
+	defer os.RemoveAll(tmpdir)
+
+	q, err := NewDiskQueue(tmpdir)
+	if err != nil {
+		t.Fatalf("Error opening queue: %v", err)
+	}
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC)
+	options := Placeholders{
+		{Name: "text", Type: "string", Value: "hi"},
+		{Name: "count", Type: "int", Value: 3},
+		{Name: "ratio", Type: "float64", Value: 0.5},
+		{Name: "tags", Type: "[]string", Value: []string{"a", "b"}},
+		{Name: "time", Type: "timestamp", Value: ts},
+	}
+	if _, err := q.Push(Event{Bee: "testbee", Name: "typed", Options: options}); err != nil {
+		t.Fatalf("Error pushing event: %v", err)
+	}
+	q.Close()
+
+	q, err = NewDiskQueue(tmpdir)
+	if err != nil {
+		t.Fatalf("Error re-opening queue: %v", err)
+	}
+	defer q.Close()
+
+	r := q.Restored()
+	if len(r) != 1 {
+		t.Fatalf("Expected 1 restored event, got %d", len(r))
+	}
+	for _, ph := range options {
+		v := r[0].Event.Options.Value(ph.Name)
+		if ph.Type == "timestamp" {
+			if tv, ok := v.(time.Time); !ok || !tv.Equal(ts) {
+				t.Errorf("Expected placeholder %s to be restored as %v, got %#v", ph.Name, ts, v)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(v, ph.Value) {
+			t.Errorf("Expected placeholder %s to be restored as %#v, got %#v", ph.Name, ph.Value, v)
+		}
+	}
+}
+
+func TestDiskQueueEmittedEvents(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "beehivetest")
+	if err != nil {
+		t.Fatal("Could not create temp directory")
+	}
+	defer os.RemoveAll(tmpdir)
+
+	q, err := NewDiskQueue(tmpdir)
+	if err != nil {
+		t.Fatalf("Error opening queue: %v", err)
+	}
+	defer q.Close()
+
+	e := NewEngine()
+	e.SetEventQueue(q)
+	e.emitEvent(Event{Bee: "chain:persisted", Name: "output"})
+
+	p := q.Pending()
+	if len(p) != 1 || p[0].Event.Bee != "chain:persisted" {
+		t.Errorf("Expected the emitted event to be persisted, got %+v", p)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"errors"
+	"sync"
+)
+
+// Registry holds the bees, hive factories, chains and actions known to the
+// engine. It is safe for concurrent use. Chains and actions are returned as
+// snapshots, which stay untouched when the registry gets modified later on.
+type Registry struct {
+	mutex sync.RWMutex
+
+	bees      map[string]*BeeInterface
+	factories map[string]*BeeFactoryInterface
+	chains    []Chain
+	actions   []Action
+}
+
+// NewRegistry returns a new, empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		bees:      make(map[string]*BeeInterface),
+		factories: make(map[string]*BeeFactoryInterface),
+	}
+}
+
+// RegisterBee adds a bee to the registry, replacing any bee of the same name.
+func (r *Registry) RegisterBee(bee BeeInterface) *BeeInterface {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.bees[bee.Name()] = &bee
+	return &bee
+}
+
+// Bee returns the bee with a specific name, or nil.
+func (r *Registry) Bee(name string) *BeeInterface {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.bees[name]
+}
+
+// Bees returns all registered bees.
+func (r *Registry) Bees() []*BeeInterface {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	bs := []*BeeInterface{}
+	for _, bee := range r.bees {
+		bs = append(bs, bee)
+	}
+
+	return bs
+}
+
+// RemoveBee removes the bee with a specific name from the registry.
+func (r *Registry) RemoveBee(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.bees, name)
+}
+
+// ClearBees removes all bees from the registry and returns them.
+func (r *Registry) ClearBees() []*BeeInterface {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	bs := []*BeeInterface{}
+	for _, bee := range r.bees {
+		bs = append(bs, bee)
+	}
+	r.bees = make(map[string]*BeeInterface)
+
+	return bs
+}
+
+// RegisterFactory adds a hive factory to the registry.
+func (r *Registry) RegisterFactory(factory BeeFactoryInterface) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.factories[factory.ID()] = &factory
+}
+
+// Factory returns the factory with a specific ID, or nil.
+func (r *Registry) Factory(id string) *BeeFactoryInterface {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.factories[id]
+}
+
+// Factories returns all registered factories.
+func (r *Registry) Factories() []*BeeFactoryInterface {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	fs := []*BeeFactoryInterface{}
+	for _, factory := range r.factories {
+		fs = append(fs, factory)
+	}
+
+	return fs
+}
+
+// Chains returns a snapshot of all chains.
+func (r *Registry) Chains() []Chain {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]Chain{}, r.chains...)
+}
+
+// Chain returns a copy of the chain with a specific name, or nil.
+func (r *Registry) Chain(name string) *Chain {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for i := range r.chains {
+		if r.chains[i].Name == name {
+			c := r.chains[i]
+			return &c
+		}
+	}
+
+	return nil
+}
+
+// SetChains replaces all chains. Chains still using the old, element based
+// format get migrated, which adds their actions to the registry.
+func (r *Registry) SetChains(cs []Chain) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	newcs := []Chain{}
+	// migrate old chain style
+	for _, c := range cs {
+		for _, el := range c.Elements {
+			if el.Action.Name != "" {
+				el.Action.ID = UUID()
+				c.Actions = append(c.Actions, el.Action.ID)
+				r.actions = append(r.actions, el.Action)
+			}
+			if el.Filter.Name != "" {
+				//FIXME: migrate old style filters
+				c.Filters = append(c.Filters, el.Filter.Options.Value.(string))
+			}
+		}
+		c.Elements = []ChainElement{}
+
+		newcs = append(newcs, c)
+	}
+
+	r.chains = newcs
+}
+
+// AddChain adds a chain, unless a chain with the same name exists already.
+func (r *Registry) AddChain(c Chain) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, ch := range r.chains {
+		if ch.Name == c.Name {
+			return errors.New("A Chain with that name exists already")
+		}
+	}
+
+	r.chains = append(append([]Chain{}, r.chains...), c)
+	return nil
+}
+
+// DeleteChain removes the chain with a specific name. Returns false if there
+// was no such chain.
+func (r *Registry) DeleteChain(name string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cs := []Chain{}
+	for _, c := range r.chains {
+		if c.Name != name {
+			cs = append(cs, c)
+		}
+	}
+	if len(cs) == len(r.chains) {
+		return false
+	}
+
+	r.chains = cs
+	return true
+}
+
+// Actions returns a snapshot of all actions.
+func (r *Registry) Actions() []Action {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]Action{}, r.actions...)
+}
+
+// Action returns a copy of the action with a specific ID, or nil.
+func (r *Registry) Action(id string) *Action {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for i := range r.actions {
+		if r.actions[i].ID == id {
+			a := r.actions[i]
+			return &a
+		}
+	}
+
+	return nil
+}
+
+// SetActions replaces all actions.
+func (r *Registry) SetActions(as []Action) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.actions = append([]Action{}, as...)
+}
+
+// AddAction adds an action.
+func (r *Registry) AddAction(a Action) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.actions = append(append([]Action{}, r.actions...), a)
+}
@@ -0,0 +1,68 @@
+package bees
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRegistrySnapshots(t *testing.T) {
+	r := NewRegistry()
+	r.SetChains([]Chain{{Name: "a"}, {Name: "b"}})
+	r.SetActions([]Action{{ID: "x"}})
+
+	cs := r.Chains()
+	cs[0].Name = "changed"
+	if c := r.Chain("a"); c == nil || c.Name != "a" {
+		t.Errorf("Modifying a snapshot must not change the registry, got %+v", r.Chains())
+	}
+	r.Chain("b").Description = "changed"
+	if c := r.Chain("b"); c.Description != "" {
+		t.Errorf("Modifying a returned chain must not change the registry, got %+v", c)
+	}
+
+	if err := r.AddChain(Chain{Name: "a"}); err == nil {
+		t.Error("Expected an error adding a duplicate chain")
+	}
+	if !r.DeleteChain("a") || r.DeleteChain("a") || len(r.Chains()) != 1 {
+		t.Errorf("Unexpected chains after deletion: %+v", r.Chains())
+	}
+
+	as := r.Actions()
+	r.AddAction(Action{ID: "y"})
+	if len(as) != 1 || len(r.Actions()) != 2 || r.Action("y") == nil {
+		t.Errorf("Unexpected actions: %+v %+v", as, r.Actions())
+	}
+}
+
+func TestRegistryConcurrency(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterFactory(&testBeeFactory{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("bee%d-%d", i, j)
+				factory := r.Factory("testbee")
+				r.RegisterBee((*factory).New(name, "", BeeOptions{}))
+				r.AddChain(Chain{Name: name})
+				r.AddAction(Action{ID: name})
+
+				for _, c := range r.Chains() {
+					r.Action(c.Name)
+				}
+				r.Bees()
+				r.RemoveBee(name)
+				r.DeleteChain(name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(r.Bees()) != 0 || len(r.Chains()) != 0 || len(r.Actions()) != 800 {
+		t.Errorf("Unexpected registry state: %d bees, %d chains, %d actions", len(r.Bees()), len(r.Chains()), len(r.Actions()))
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = 5 * time.Minute
+)
+
+// RetryPolicy describes how a failed action gets retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total amount of tries, including the first one
+	MaxAttempts int
+	// Backoff is the delay before the first retry, e.g. "2s". It doubles
+	// with every further attempt
+	Backoff string
+	// MaxBackoff caps the delay between two attempts, e.g. "5m"
+	MaxBackoff string
+	// Jitter randomizes each delay by up to this fraction (0.0 - 1.0)
+	Jitter float64
+	// RetryOnPanic decides whether a panicking action should be retried
+	RetryOnPanic bool
+}
+
+// attempts returns how often an action should be tried in total.
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// delay returns how long to wait after the given (failed) attempt.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	backoff := parseDuration(p.Backoff, defaultRetryBackoff)
+	max := parseDuration(p.MaxBackoff, defaultRetryMaxBackoff)
+
+	d := backoff
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	if p.Jitter > 0 {
+		j := p.Jitter
+		if j > 1 {
+			j = 1
+		}
+		d = time.Duration(float64(d) * (1 - j + 2*j*rand.Float64()))
+	}
+
+	return d
+}
+
+func parseDuration(s string, def time.Duration) time.Duration {
+	if len(s) == 0 {
+		return def
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Errorf("Invalid duration '%s': %v", s, err)
+		return def
+	}
+
+	return d
+}
+
+// runAction executes an action on a bee and retries it according to the
+// action's RetryPolicy. Actions that failed permanently end up in the
+// dead-letter store. While waiting for a retry, the action hands back its
+// worker w, if it runs on one.
+func (e *Engine) runAction(bee *BeeInterface, a Action, w *worker) ([]Placeholder, error) {
+	attempts := a.Retry.attempts()
+
+	for attempt := 1; ; attempt++ {
+		phs, panicked, err := invokeAction(bee, a)
+		if err == nil {
+			if (*bee).Status().State == BeeDegraded {
+				(*bee).SetState(BeeRunning, nil)
+			}
+			return phs, nil
+		}
+
+		retryable := !panicked || (a.Retry != nil && a.Retry.RetryOnPanic)
+		if attempt >= attempts || !retryable {
+			(*bee).LogErrorf("Action %s failed after %d attempt(s): %v", a.Name, attempt, err)
+			e.addDeadLetter(a, err, attempt)
+			if (*bee).Status().State == BeeRunning {
+				(*bee).SetState(BeeDegraded, err)
+			}
+			return nil, err
+		}
+
+		d := a.Retry.delay(attempt)
+		(*bee).Logf("Action %s failed (attempt %d of %d), retrying in %s: %v", a.Name, attempt, attempts, d, err)
+		w.wait(d)
+	}
+}
+
+// invokeAction calls a bee's ActionWithError method, or its Action method if
+// it doesn't implement one, and turns panics into errors.
+func invokeAction(bee *BeeInterface, a Action) (phs []Placeholder, panicked bool, err error) {
+	start := time.Now()
+	actionInvocationsTotal.WithLabelValues(a.Bee, a.Name).Inc()
+
+	defer func() {
+		if e := recover(); e != nil {
+			panicked = true
+			err = fmt.Errorf("%v", e)
+		}
+
+		actionDuration.WithLabelValues(a.Bee, a.Name).Observe(time.Since(start).Seconds())
+		if err != nil {
+			actionErrorsTotal.WithLabelValues(a.Bee, a.Name).Inc()
+		}
+	}()
+
+	if b, ok := (*bee).(ActionWithErrorInterface); ok {
+		phs, err = b.ActionWithError(a)
+		return phs, false, err
+	}
+
+	return (*bee).Action(a), false, nil
+}
@@ -0,0 +1,110 @@
+package bees
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakyBee struct {
+	Bee
+	failures int
+	calls    int
+}
+
+func (bee *flakyBee) ReloadOptions(options BeeOptions) {
+}
+
+func (bee *flakyBee) Action(action Action) []Placeholder {
+	bee.calls++
+	if bee.calls <= bee.failures {
+		panic("temporary failure")
+	}
+
+	return []Placeholder{{Name: "calls", Type: "int", Value: bee.calls}}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := &RetryPolicy{Backoff: "1s", MaxBackoff: "5s"}
+	cases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+	for _, c := range cases {
+		if d := p.delay(c.attempt); d != c.expected {
+			t.Errorf("Expected delay %s after attempt %d, got %s", c.expected, c.attempt, d)
+		}
+	}
+
+	var nilPolicy *RetryPolicy
+	if nilPolicy.attempts() != 1 {
+		t.Error("Actions without a retry policy should only be tried once")
+	}
+}
+
+func TestRunActionRetries(t *testing.T) {
+	fb := &flakyBee{Bee: NewBee("flaky", "flakybee", "", BeeOptions{}), failures: 2}
+	var bee BeeInterface = fb
+
+	a := Action{
+		ID:    "retrying",
+		Bee:   "flaky",
+		Name:  "test",
+		Retry: &RetryPolicy{MaxAttempts: 3, Backoff: "1ms", RetryOnPanic: true},
+	}
+	phs, err := defaultEngine.runAction(&bee, a, nil)
+	if err != nil {
+		t.Fatalf("Expected action to succeed after retrying, got: %v", err)
+	}
+	if fb.calls != 3 || Placeholders(phs).Value("calls") != 3 {
+		t.Errorf("Expected 3 calls, got %d", fb.calls)
+	}
+
+	fb.calls = 0
+	a.Retry.RetryOnPanic = false
+	_, err = defaultEngine.runAction(&bee, a, nil)
+	if err == nil || fb.calls != 1 {
+		t.Errorf("Expected a panicking action not to be retried, got %d calls", fb.calls)
+	}
+
+	dl := GetDeadLetters()
+	if len(dl) == 0 || dl[0].Action.ID != "retrying" || dl[0].Attempts != 1 {
+		t.Fatalf("Expected failed action to end up in the dead-letter store, got %+v", dl)
+	}
+	DeleteDeadLetter(dl[0].ID)
+}
+
+type erroringBee struct {
+	Bee
+}
+
+func (bee *erroringBee) ReloadOptions(options BeeOptions) {
+}
+
+func (bee *erroringBee) ActionWithError(action Action) ([]Placeholder, error) {
+	if action.Name == "fail" {
+		return nil, errors.New("failed on purpose")
+	}
+
+	return []Placeholder{{Name: "ok", Type: "bool", Value: true}}, nil
+}
+
+func TestRunActionWithError(t *testing.T) {
+	var bee BeeInterface = &erroringBee{Bee: NewBee("erroring", "erroringbee", "", BeeOptions{})}
+
+	phs, err := defaultEngine.runAction(&bee, Action{Bee: "erroring", Name: "succeed"}, nil)
+	if err != nil || len(phs) != 1 {
+		t.Errorf("Expected the action to succeed, got %v %+v", err, phs)
+	}
+
+	_, err = defaultEngine.runAction(&bee, Action{Bee: "erroring", Name: "fail"}, nil)
+	if err == nil || err.Error() != "failed on purpose" {
+		t.Errorf("Expected the action's error to be returned, got %v", err)
+	}
+}
@@ -0,0 +1,376 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// overdue actions restored on startup get delayed by this, so their bees had
+// a chance to start
+const scheduleRestoreDelay = 5 * time.Second
+
+// ActionSchedule postpones the execution of a chain's action.
+type ActionSchedule struct {
+	// Delay runs the action after this duration, e.g. "10m"
+	Delay string `json:",omitempty" yaml:",omitempty"`
+	// At is a template rendering the time the action runs at, either in RFC
+	// 3339 format or as a unix timestamp, e.g. "{{.start}}"
+	At string `json:",omitempty" yaml:",omitempty"`
+	// Key is an optional template, e.g. "{{.room}}". Scheduling the action
+	// cancels its pending executions with the same rendered key. Events
+	// which don't reach the action, e.g. because a filter rejected them,
+	// don't cancel anything; use CancelScheduledActions for that
+	Key string `json:",omitempty" yaml:",omitempty"`
+}
+
+// ScheduledAction is an action waiting to be executed. The action's options
+// are already rendered.
+type ScheduledAction struct {
+	ID    string
+	Chain string
+	// Execution is the ID of the chain execution which scheduled the action
+	Execution string `json:",omitempty"`
+	Key       string
+	Action    Action
+	Due       time.Time
+	Created   time.Time
+}
+
+type scheduledEntry struct {
+	ScheduledAction
+	timer *time.Timer
+}
+
+// Validate checks the schedule for invalid values.
+func (s *ActionSchedule) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if len(s.Delay) == 0 && len(s.At) == 0 {
+		return errors.New("Schedules require either a delay or a time")
+	}
+	if len(s.Delay) > 0 {
+		if _, err := time.ParseDuration(s.Delay); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// due returns the time an action scheduled now should run at.
+func (s *ActionSchedule) due(name string, m map[string]interface{}) (time.Time, error) {
+	now := time.Now()
+	if len(s.At) == 0 {
+		d, err := time.ParseDuration(s.Delay)
+		return now.Add(d), err
+	}
+
+	at, err := renderTemplate(name+"_at", s.At, m)
+	if err != nil {
+		return now, err
+	}
+	at = strings.TrimSpace(at)
+	if ts, err := strconv.ParseInt(at, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+
+	return time.Parse(time.RFC3339, at)
+}
+
+// SetSchedulePath enables persisting scheduled actions in a file and
+// restores the actions that were pending when it was last written.
+func SetSchedulePath(path string) error {
+	return defaultEngine.SetSchedulePath(path)
+}
+
+// SetSchedulePath sets where the engine persists its scheduled actions.
+func (e *Engine) SetSchedulePath(path string) error {
+	e.scheduleMutex.Lock()
+	defer e.scheduleMutex.Unlock()
+
+	e.schedulePath = path
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var sas []ScheduledAction
+	err = json.Unmarshal(b, &sas)
+	if err != nil {
+		return err
+	}
+
+	for _, sa := range sas {
+		if _, ok := e.scheduled[sa.ID]; !ok {
+			e.armScheduledAction(sa, scheduleRestoreDelay)
+		}
+	}
+	log.Infof("Restored %d scheduled action(s)", len(sas))
+
+	return nil
+}
+
+// scheduleAction renders an action of a chain and schedules its execution.
+// Pending executions of the same action with the same key get cancelled.
+// execution is the ID of the chain execution scheduling the action.
+func (e *Engine) scheduleAction(c Chain, action Action, m map[string]interface{}, execution string) (ScheduledAction, error) {
+	name := action.Bee + "_" + action.Name
+	sa := ScheduledAction{
+		ID:        UUID(),
+		Chain:     c.Name,
+		Execution: execution,
+		Created:   time.Now(),
+	}
+
+	a, err := renderAction(action, m)
+	if err != nil {
+		return sa, err
+	}
+	sa.Action = a
+
+	sa.Due, err = action.Schedule.due(name, m)
+	if err != nil {
+		return sa, err
+	}
+	if len(action.Schedule.Key) > 0 {
+		sa.Key, err = renderTemplate(name+"_schedulekey", action.Schedule.Key, m)
+		if err != nil {
+			return sa, err
+		}
+	}
+
+	e.scheduleMutex.Lock()
+	defer e.scheduleMutex.Unlock()
+
+	for id, se := range e.scheduled {
+		if se.Chain == sa.Chain && se.Action.ID == sa.Action.ID && se.Key == sa.Key {
+			log.Debugln("\t\tCancelling scheduled action:", id)
+			se.timer.Stop()
+			delete(e.scheduled, id)
+		}
+	}
+	e.armScheduledAction(sa, 0)
+	e.saveSchedule()
+
+	return sa, nil
+}
+
+// armScheduledAction starts the timer of a scheduled action. The caller must
+// hold the scheduleMutex.
+func (e *Engine) armScheduledAction(sa ScheduledAction, minDelay time.Duration) {
+	d := time.Until(sa.Due)
+	if d < minDelay {
+		d = minDelay
+	}
+
+	e.scheduled[sa.ID] = &scheduledEntry{
+		ScheduledAction: sa,
+		timer: time.AfterFunc(d, func() {
+			e.runScheduledAction(sa.ID)
+		}),
+	}
+}
+
+// runScheduledAction executes a scheduled action once it's due. The run gets
+// recorded as an execution of the chain which scheduled the action, linked
+// to the original execution.
+func (e *Engine) runScheduledAction(id string) {
+	e.scheduleMutex.Lock()
+	se, ok := e.scheduled[id]
+	if ok {
+		delete(e.scheduled, id)
+		e.saveSchedule()
+	}
+	e.scheduleMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	var event Event
+	if origin := e.GetExecution(se.Execution); origin != nil {
+		event = origin.Event
+	}
+	x := e.newExecution(Chain{Name: se.Chain}, event)
+	x.Origin = se.Execution
+
+	a := se.Action
+	trace := ActionTrace{
+		ID:        a.ID,
+		Bee:       a.Bee,
+		Name:      a.Name,
+		Options:   a.Options,
+		Scheduled: se.Due,
+		Timestamp: time.Now(),
+	}
+
+	var err error
+	bee := e.GetBee(a.Bee)
+	if bee == nil || !(*bee).IsRunning() {
+		err = errors.New("Bee " + a.Bee + " is not running")
+		log.Errorln("Failed to execute scheduled action:", err)
+		e.addDeadLetter(a, err, 0)
+	} else {
+		log.Debugln("Executing scheduled action:", a.Bee, "/", a.Name)
+		(*bee).LogAction()
+		trace.Placeholders, err = e.runAction(bee, a, nil)
+	}
+
+	trace.Duration = time.Since(trace.Timestamp)
+	status := ExecutionCompleted
+	if err != nil {
+		trace.Error = err.Error()
+		status = ExecutionFailed
+	}
+	x.Actions = append(x.Actions, trace)
+	x.finish(status)
+}
+
+// saveSchedule persists all pending scheduled actions. The caller must hold
+// the scheduleMutex.
+func (e *Engine) saveSchedule() {
+	if len(e.schedulePath) == 0 {
+		return
+	}
+
+	b, err := json.MarshalIndent(e.sortedSchedule(), "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(e.schedulePath+".tmp", b, 0600)
+	}
+	if err == nil {
+		err = os.Rename(e.schedulePath+".tmp", e.schedulePath)
+	}
+	if err != nil {
+		log.Errorln("Failed to persist scheduled actions:", err)
+	}
+}
+
+// sortedSchedule returns all pending scheduled actions, the next due first.
+// The caller must hold the scheduleMutex.
+func (e *Engine) sortedSchedule() []ScheduledAction {
+	r := []ScheduledAction{}
+	for _, se := range e.scheduled {
+		r = append(r, se.ScheduledAction)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Due.Before(r[j].Due)
+	})
+
+	return r
+}
+
+// GetScheduledActions returns all pending scheduled actions, the next due
+// first.
+func GetScheduledActions() []ScheduledAction {
+	return defaultEngine.GetScheduledActions()
+}
+
+// GetScheduledActions returns the engine's pending scheduled actions.
+func (e *Engine) GetScheduledActions() []ScheduledAction {
+	e.scheduleMutex.Lock()
+	defer e.scheduleMutex.Unlock()
+
+	return e.sortedSchedule()
+}
+
+// GetScheduledAction returns the pending scheduled action with a specific ID.
+func GetScheduledAction(id string) *ScheduledAction {
+	return defaultEngine.GetScheduledAction(id)
+}
+
+// GetScheduledAction returns the engine's scheduled action with a specific ID.
+func (e *Engine) GetScheduledAction(id string) *ScheduledAction {
+	e.scheduleMutex.Lock()
+	defer e.scheduleMutex.Unlock()
+
+	se, ok := e.scheduled[id]
+	if !ok {
+		return nil
+	}
+
+	sa := se.ScheduledAction
+	return &sa
+}
+
+// CancelScheduledAction cancels a pending scheduled action. Returns false if
+// there was no such action.
+func CancelScheduledAction(id string) bool {
+	return defaultEngine.CancelScheduledAction(id)
+}
+
+// CancelScheduledAction cancels one of the engine's scheduled actions.
+func (e *Engine) CancelScheduledAction(id string) bool {
+	e.scheduleMutex.Lock()
+	defer e.scheduleMutex.Unlock()
+
+	se, ok := e.scheduled[id]
+	if !ok {
+		return false
+	}
+
+	se.timer.Stop()
+	delete(e.scheduled, id)
+	e.saveSchedule()
+
+	return true
+}
+
+// CancelScheduledActions cancels all pending executions of an action with a
+// specific key, no matter which chain scheduled them. Returns how many got
+// cancelled.
+func CancelScheduledActions(action, key string) int {
+	return defaultEngine.CancelScheduledActions(action, key)
+}
+
+// CancelScheduledActions cancels the engine's pending executions of an
+// action with a specific key.
+func (e *Engine) CancelScheduledActions(action, key string) int {
+	e.scheduleMutex.Lock()
+	defer e.scheduleMutex.Unlock()
+
+	n := 0
+	for id, se := range e.scheduled {
+		if se.Action.ID == action && se.Key == key {
+			se.timer.Stop()
+			delete(e.scheduled, id)
+			n++
+		}
+	}
+	if n > 0 {
+		e.saveSchedule()
+	}
+
+	return n
+}
@@ -0,0 +1,155 @@
+package bees
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduledActions(t *testing.T) {
+	bee := startTestBee("scheduletest")
+	defer DeleteBee(GetBee("scheduletest"))
+
+	SetActions([]Action{{
+		ID:       "lightoff",
+		Bee:      "scheduletest",
+		Name:     "echo",
+		Options:  Placeholders{{Name: "text", Type: "string", Value: "{{.text}} off"}},
+		Schedule: &ActionSchedule{Delay: "50ms", Key: "{{.text}}"},
+	}})
+	SetChains([]Chain{{
+		Name:    "motion",
+		Event:   &Event{Bee: "scheduletest", Name: "message"},
+		Actions: []string{"lightoff"},
+	}})
+	defer SetChains([]Chain{})
+
+	for _, room := range []string{"kitchen", "hall", "kitchen"} {
+		defaultEngine.execChains(&Event{
+			Bee:     "scheduletest",
+			Name:    "message",
+			Options: Placeholders{{Name: "text", Type: "string", Value: room}},
+		}, nil, nil)
+	}
+
+	sas := GetScheduledActions()
+	if len(sas) != 2 {
+		t.Fatalf("Expected a later event to replace the pending action of the same key, got %+v", sas)
+	}
+	if len(bee.recorded()) != 0 {
+		t.Error("Scheduled actions must not be executed right away")
+	}
+	for _, sa := range sas {
+		if sa.Key == "hall" && !CancelScheduledAction(sa.ID) {
+			t.Error("Failed to cancel scheduled action")
+		}
+	}
+
+	bee.waitForActions(1)
+	// give the cancelled action a chance to show up
+	time.Sleep(100 * time.Millisecond)
+	actions := bee.recorded()
+	if len(actions) != 1 || actions[0].Options.Value("text") != "kitchen off" {
+		t.Errorf("Expected only the kitchen light to be turned off, got %+v", actions)
+	}
+	if len(GetScheduledActions()) != 0 {
+		t.Errorf("Expected no more pending actions, got %+v", GetScheduledActions())
+	}
+	defaultEngine.execChains(&Event{
+		Bee:     "scheduletest",
+		Name:    "message",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "hall"}},
+	}, nil, nil)
+	if n := CancelScheduledActions("lightoff", "kitchen"); n != 0 {
+		t.Errorf("Expected no pending action for the kitchen, cancelled %d", n)
+	}
+	if n := CancelScheduledActions("lightoff", "hall"); n != 1 {
+		t.Errorf("Expected to cancel the pending action for the hall, cancelled %d", n)
+	}
+	if len(GetScheduledActions()) != 0 {
+		t.Errorf("Expected no more pending actions, got %+v", GetScheduledActions())
+	}
+}
+
+func TestScheduledActionExecution(t *testing.T) {
+	e := NewEngine()
+	bee := startEngineTestBee(e, "scheduletrace")
+	defer e.DeleteBee(e.GetBee("scheduletrace"))
+
+	e.SetActions([]Action{{
+		ID:       "later",
+		Bee:      "scheduletrace",
+		Name:     "echo",
+		Options:  Placeholders{{Name: "text", Type: "string", Value: "{{.text}} later"}},
+		Schedule: &ActionSchedule{Delay: "20ms"},
+	}})
+	e.SetChains([]Chain{{
+		Name:    "delayed",
+		Event:   &Event{Bee: "scheduletrace", Name: "message"},
+		Actions: []string{"later"},
+	}})
+	defer e.SetChains([]Chain{})
+
+	e.execChains(&Event{
+		Bee:     "scheduletrace",
+		Name:    "message",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "see you"}},
+	}, nil, nil)
+	bee.waitForActions(1)
+	time.Sleep(10 * time.Millisecond)
+
+	xs := e.GetExecutions(ExecutionQuery{Chain: "delayed"})
+	if len(xs) != 2 {
+		t.Fatalf("Expected the scheduled run to be recorded as an execution, got %+v", xs)
+	}
+	run, origin := xs[0], xs[1]
+	if run.Origin != origin.ID || run.Status != ExecutionCompleted || run.Event.Bee != "scheduletrace" {
+		t.Errorf("Expected a completed execution linked to %s, got %+v", origin.ID, run)
+	}
+	if len(run.Actions) != 1 || run.Actions[0].Options.Value("text") != "see you later" || run.Actions[0].Scheduled.IsZero() {
+		t.Errorf("Expected the scheduled action in the execution trace, got %+v", run.Actions)
+	}
+}
+
+func TestScheduledActionsPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beehive-schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() {
+		defaultEngine.schedulePath = ""
+	}()
+
+	path := filepath.Join(dir, "scheduled.json")
+	if err := SetSchedulePath(path); err != nil {
+		t.Fatal(err)
+	}
+
+	action := Action{ID: "later", Bee: "nobody", Name: "echo", Schedule: &ActionSchedule{At: "{{.at}}"}}
+	due := time.Now().Add(time.Hour).Truncate(time.Second)
+	sa, err := defaultEngine.scheduleAction(Chain{Name: "persisted"}, action, map[string]interface{}{"at": due.Unix()}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sa.Due.Equal(due) {
+		t.Errorf("Expected action to be due at %v, got %v", due, sa.Due)
+	}
+
+	// forget about the action, then restore it
+	defaultEngine.scheduleMutex.Lock()
+	defaultEngine.scheduled[sa.ID].timer.Stop()
+	delete(defaultEngine.scheduled, sa.ID)
+	defaultEngine.scheduleMutex.Unlock()
+
+	if err := SetSchedulePath(path); err != nil {
+		t.Fatal(err)
+	}
+	r := GetScheduledAction(sa.ID)
+	if r == nil || r.Chain != "persisted" || !r.Due.Equal(due) {
+		t.Fatalf("Expected the scheduled action to be restored, got %+v", r)
+	}
+	CancelScheduledAction(sa.ID)
+}
@@ -24,6 +24,7 @@ package serialbee
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"io"
 
 	"github.com/jacobsa/go-serial/serial"
@@ -41,31 +42,33 @@ type SerialBee struct {
 	baudrate int
 }
 
-// Action triggers the action passed to it.
-func (mod *SerialBee) Action(action bees.Action) []bees.Placeholder {
+// ActionWithError triggers the action passed to it.
+func (mod *SerialBee) ActionWithError(action bees.Action) ([]bees.Placeholder, error) {
 	outs := []bees.Placeholder{}
 	text := ""
 
 	switch action.Name {
 	case "send":
-		action.Options.Bind("text", &text)
+		if err := action.Options.Bind("text", &text); err != nil {
+			return outs, err
+		}
 
 		bufOut := new(bytes.Buffer)
 		err := binary.Write(bufOut, binary.LittleEndian, []byte(text))
 		if err != nil {
-			panic(err)
+			return outs, err
 		}
 
 		_, err = mod.conn.Write(bufOut.Bytes())
 		if err != nil {
-			panic(err)
+			return outs, err
 		}
 
 	default:
-		panic("Unknown action triggered in " + mod.Name() + ": " + action.Name)
+		return outs, errors.New("Unknown action triggered in " + mod.Name() + ": " + action.Name)
 	}
 
-	return outs
+	return outs, nil
 }
 
 func (mod *SerialBee) handleEvents(eventChan chan bees.Event) error {
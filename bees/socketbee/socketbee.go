@@ -22,7 +22,7 @@
 package socketbee
 
 import (
-	"log"
+	"errors"
 	"net"
 	"strconv"
 
@@ -46,43 +46,49 @@ func (mod *SocketBee) Run(cin chan bees.Event) {
 	}
 }
 
-// Action triggers the action passed to it.
-func (mod *SocketBee) Action(action bees.Action) []bees.Placeholder {
+// ActionWithError triggers the action passed to it.
+func (mod *SocketBee) ActionWithError(action bees.Action) ([]bees.Placeholder, error) {
 	outs := []bees.Placeholder{}
 
 	var data string
 	var addr string
 	var port int
 
-	action.Options.Bind("address", &addr)
-	action.Options.Bind("port", &port)
-	action.Options.Bind("data", &data)
-
 	switch action.Name {
 	case "send":
+		if err := action.Options.Bind("address", &addr); err != nil {
+			return outs, err
+		}
+		if err := action.Options.Bind("port", &port); err != nil {
+			return outs, err
+		}
+		if err := action.Options.Bind("data", &data); err != nil {
+			return outs, err
+		}
+
 		// log.Println("Sending", data, "to", addr, port)
 
 		sa, err := net.ResolveUDPAddr("udp", addr+":"+strconv.Itoa(port))
 		if err != nil {
-			log.Panicln(err)
+			return outs, err
 		}
 
 		conn, err := net.DialUDP("udp", nil, sa)
 		if err != nil {
-			log.Panicln(err)
+			return outs, err
 		}
 
 		defer conn.Close()
 		_, err = conn.Write([]byte(data))
 		if err != nil {
-			log.Panicln(err)
+			return outs, err
 		}
 
 	default:
-		panic("Unknown action triggered in " + mod.Name() + ": " + action.Name)
+		return outs, errors.New("Unknown action triggered in " + mod.Name() + ": " + action.Name)
 	}
 
-	return outs
+	return outs, nil
 }
 
 // ReloadOptions parses the config options and initializes the Bee.
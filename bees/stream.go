@@ -0,0 +1,221 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Types of messages published to stream subscribers
+const (
+	StreamEvent     = "event"
+	StreamExecution = "execution"
+	StreamLog       = "log"
+)
+
+// amount of messages buffered per subscriber before we start dropping them
+const streamBufferSize = 256
+
+// StreamMessage is a single message published to stream subscribers. Only
+// the field matching its Type is set.
+type StreamMessage struct {
+	Type      string
+	Event     *Event
+	Execution *Execution
+	Log       *LogMessage
+}
+
+// StreamFilter decides which messages a subscriber receives. Empty fields
+// match everything. Event only applies to events & executions, Level only to
+// log messages, which are delivered if they're at least as severe.
+type StreamFilter struct {
+	Types []string
+	Bee   string
+	Event string
+	Level *MessageType
+}
+
+// Subscription receives all stream messages matching its filter on C.
+// Messages get dropped if the subscriber can't keep up.
+type Subscription struct {
+	C <-chan StreamMessage
+
+	c       chan StreamMessage
+	filter  StreamFilter
+	dropped uint64
+}
+
+// Subscribe registers a new stream subscriber.
+func Subscribe(filter StreamFilter) *Subscription {
+	return defaultEngine.Subscribe(filter)
+}
+
+// Subscribe registers a new subscriber to the engine's stream.
+func (e *Engine) Subscribe(filter StreamFilter) *Subscription {
+	c := make(chan StreamMessage, streamBufferSize)
+	s := &Subscription{
+		C:      c,
+		c:      c,
+		filter: filter,
+	}
+
+	e.subscriptionMutex.Lock()
+	defer e.subscriptionMutex.Unlock()
+	e.subscriptions[s] = struct{}{}
+
+	return s
+}
+
+// Unsubscribe removes a stream subscriber and closes its channel.
+func Unsubscribe(s *Subscription) {
+	defaultEngine.Unsubscribe(s)
+}
+
+// Unsubscribe removes a subscriber from the engine's stream and closes its
+// channel.
+func (e *Engine) Unsubscribe(s *Subscription) {
+	e.subscriptionMutex.Lock()
+	defer e.subscriptionMutex.Unlock()
+
+	if _, ok := e.subscriptions[s]; ok {
+		delete(e.subscriptions, s)
+		close(s.c)
+	}
+}
+
+// Dropped returns how many messages got dropped for this subscriber.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// publish sends a message to all interested subscribers, without ever
+// blocking the caller.
+func (e *Engine) publish(m StreamMessage) {
+	e.subscriptionMutex.RLock()
+	defer e.subscriptionMutex.RUnlock()
+
+	for s := range e.subscriptions {
+		if !s.filter.matches(m) {
+			continue
+		}
+
+		select {
+		case s.c <- m:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+func (f StreamFilter) matches(m StreamMessage) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == m.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	var bee, event string
+	switch m.Type {
+	case StreamEvent:
+		bee, event = m.Event.Bee, m.Event.Name
+	case StreamExecution:
+		bee, event = m.Execution.Event.Bee, m.Execution.Event.Name
+	case StreamLog:
+		bee = m.Log.Bee
+		if f.Level != nil && logSeverity(MessageType(m.Log.MessageType)) < logSeverity(*f.Level) {
+			return false
+		}
+	}
+
+	if len(f.Bee) > 0 && f.Bee != bee {
+		return false
+	}
+	if len(f.Event) > 0 && m.Type != StreamLog && f.Event != event {
+		return false
+	}
+
+	return true
+}
+
+// logSeverity ranks log levels, as their numeric values aren't ordered.
+func logSeverity(t MessageType) int {
+	switch t {
+	case LogDebug:
+		return 0
+	case LogInfo:
+		return 1
+	case LogError:
+		return 2
+	case LogFatal:
+		return 3
+	}
+
+	return 0
+}
+
+// logLevelName returns the name of a log level.
+func logLevelName(t MessageType) string {
+	switch t {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogError:
+		return "error"
+	case LogFatal:
+		return "fatal"
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+// ParseLogLevel parses a log level, either by name (debug, info, error,
+// fatal) or by its numeric value.
+func ParseLogLevel(s string) (MessageType, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LogDebug, nil
+	case "info":
+		return LogInfo, nil
+	case "error":
+		return LogError, nil
+	case "fatal":
+		return LogFatal, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil || i < int(LogInfo) || i > int(LogDebug) {
+		return 0, errors.New("Unknown log level " + s)
+	}
+
+	return MessageType(i), nil
+}
@@ -0,0 +1,52 @@
+package bees
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamFilter(t *testing.T) {
+	level := LogError
+	sub := Subscribe(StreamFilter{Bee: "streamtest", Level: &level})
+	defer Unsubscribe(sub)
+
+	events := Subscribe(StreamFilter{Types: []string{StreamEvent}, Event: "message"})
+	defer Unsubscribe(events)
+
+	Log("streamtest", "info", LogInfo)
+	Log("streamtest", "error", LogError)
+	Log("othertest", "error", LogError)
+	defaultEngine.publish(StreamMessage{Type: StreamEvent, Event: &Event{Bee: "streamtest", Name: "other"}})
+	defaultEngine.publish(StreamMessage{Type: StreamEvent, Event: &Event{Bee: "streamtest", Name: "message"}})
+
+	expect := func(s *Subscription, typ string) StreamMessage {
+		select {
+		case m := <-s.C:
+			if m.Type != typ {
+				t.Errorf("Expected a %s message, got %s", typ, m.Type)
+			}
+			return m
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %s message, got nothing", typ)
+		}
+		return StreamMessage{}
+	}
+
+	if m := expect(sub, StreamLog); m.Log.Message != "error" {
+		t.Errorf("Expected the error message, got %s", m.Log.Message)
+	}
+	// the log level doesn't apply to events
+	expect(sub, StreamEvent)
+	expect(sub, StreamEvent)
+	if m := expect(events, StreamEvent); m.Event.Name != "message" {
+		t.Errorf("Expected the message event, got %s", m.Event.Name)
+	}
+
+	select {
+	case m := <-sub.C:
+		t.Errorf("Unexpected message: %+v", m)
+	case m := <-events.C:
+		t.Errorf("Unexpected message: %+v", m)
+	default:
+	}
+}
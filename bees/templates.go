@@ -0,0 +1,41 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/muesli/beehive/templatehelper"
+)
+
+// renderTemplate executes a text template against data.
+func renderTemplate(name, text string, data interface{}) (string, error) {
+	var value bytes.Buffer
+
+	tmpl, err := template.New(name).Funcs(templatehelper.FuncMap).Parse(text)
+	if err == nil {
+		err = tmpl.Execute(&value, data)
+	}
+
+	return value.String(), err
+}
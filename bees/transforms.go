@@ -0,0 +1,87 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ChainTransform derives a new field from an event, before the chain's
+// filters get evaluated. Filters, actions and later transforms can use the
+// derived field like any of the event's placeholders.
+type ChainTransform struct {
+	// Name of the derived field
+	Name string
+	// Template renders the field's value, e.g.
+	// {{index (Submatches .text "^(\w+): (.*)$") 1}}
+	Template string
+	// Type converts the rendered value to a placeholder type like "int" or
+	// "bool". "json" decodes it into structured data. Defaults to "string"
+	Type string `json:",omitempty" yaml:",omitempty"`
+}
+
+// execTransforms derives a chain's fields and adds them to the template data.
+// Returns false if a transform failed.
+func execTransforms(c Chain, m map[string]interface{}, x *Execution) bool {
+	for _, t := range c.Transforms {
+		trace := FilterTrace{
+			Filter:    t.Template,
+			Transform: t.Name,
+		}
+
+		v, err := execTransform(c, t, m)
+		if err != nil {
+			log.Errorln("\t\tERROR: Transform", t.Name, "failed:", err)
+			trace.Error = err.Error()
+			x.Filters = append(x.Filters, trace)
+			return false
+		}
+
+		m[t.Name] = v
+		trace.Rendered = truncateString(fmt.Sprint(v), 1000)
+		trace.Passed = true
+		x.Filters = append(x.Filters, trace)
+	}
+
+	return true
+}
+
+func execTransform(c Chain, t ChainTransform, m map[string]interface{}) (interface{}, error) {
+	s, err := renderTemplate(c.Name+"_transform_"+t.Name, t.Template, m)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.Type {
+	case "", "string":
+		return s, nil
+	case "json":
+		var v interface{}
+		err = json.Unmarshal([]byte(s), &v)
+		return v, err
+	}
+
+	return convertPlaceholder(s, t.Type)
+}
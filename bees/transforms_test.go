@@ -0,0 +1,59 @@
+package bees
+
+import (
+	"testing"
+)
+
+func TestChainTransforms(t *testing.T) {
+	e := NewEngine()
+	bee := startEngineTestBee(e, "transformtest")
+	defer e.DeleteBee(e.GetBee("transformtest"))
+
+	e.SetActions([]Action{{
+		ID:      "notify",
+		Bee:     "transformtest",
+		Name:    "echo",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "{{.nick}} on {{.payload.host}} ({{.severity}})"}},
+	}})
+	c := Chain{
+		Name:  "transformed",
+		Event: &Event{Bee: "transformtest", Name: "message"},
+		Transforms: []ChainTransform{
+			{Name: "parts", Template: `{{JSON (Submatches .text "^(\\w+): (.*)$")}}`, Type: "json"},
+			{Name: "nick", Template: `{{index .parts 0 1}}`},
+			{Name: "payload", Template: `{{index .parts 0 2}}`, Type: "json"},
+			{Name: "severity", Template: `{{if eq .payload.level "crit"}}3{{else}}1{{end}}`, Type: "int"},
+		},
+		Filters: []string{`{{test gt .severity 2}}`},
+		Actions: []string{"notify"},
+	}
+	e.SetChains([]Chain{c})
+	defer e.SetChains([]Chain{})
+
+	for _, text := range []string{
+		`muesli: {"host": "db", "level": "crit"}`,
+		`muesli: {"host": "web", "level": "info"}`,
+		`not json`,
+	} {
+		e.execChains(&Event{
+			Bee:     "transformtest",
+			Name:    "message",
+			Options: Placeholders{{Name: "text", Type: "string", Value: text}},
+		}, nil, nil)
+	}
+
+	if len(bee.recorded()) != 1 || bee.recorded()[0].Options.Value("text") != "muesli on db (3)" {
+		t.Errorf("Expected the derived fields in the action, got %+v", bee.recorded())
+	}
+
+	xs := e.GetExecutions(ExecutionQuery{Chain: "transformed"})
+	if len(xs) != 3 || xs[0].Status != ExecutionFailed || len(xs[0].Filters[len(xs[0].Filters)-1].Error) == 0 {
+		t.Errorf("Expected a failing transform to fail the execution, got %+v", xs)
+	}
+	if xs[1].Status != ExecutionFiltered {
+		t.Errorf("Expected the filter to filter the event, got %s", xs[1].Status)
+	}
+	if f := xs[2].Filters[1]; f.Transform != "nick" || f.Rendered != "muesli" {
+		t.Errorf("Expected the transform in the execution trace, got %+v", f)
+	}
+}
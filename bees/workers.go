@@ -0,0 +1,396 @@
+/*
+ *    Copyright (C) 2019 Christian Muehlhaeuser
+ *
+ *    This program is free software: you can redistribute it and/or modify
+ *    it under the terms of the GNU Affero General Public License as published
+ *    by the Free Software Foundation, either version 3 of the License, or
+ *    (at your option) any later version.
+ *
+ *    This program is distributed in the hope that it will be useful,
+ *    but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *    GNU Affero General Public License for more details.
+ *
+ *    You should have received a copy of the GNU Affero General Public License
+ *    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ *    Authors:
+ *      Christian Muehlhaeuser <muesli@gmail.com>
+ */
+
+// Package bees is Beehive's central module system.
+package bees
+
+import (
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Ways the engine orders the processing of events
+const (
+	// OrderingNone processes events concurrently, in no particular order
+	OrderingNone = "none"
+	// OrderingBee processes the events of each bee one after another
+	OrderingBee = "bee"
+	// OrderingKey processes events with the same rendered key one after
+	// another
+	OrderingKey = "key"
+)
+
+const (
+	// DefaultWorkers is the amount of events processed concurrently
+	DefaultWorkers = 32
+	// DefaultWorkQueueSize is the amount of events buffered before bees get
+	// blocked
+	DefaultWorkQueueSize = 1024
+)
+
+// WorkerPoolConfig configures how an engine processes events.
+type WorkerPoolConfig struct {
+	// Workers is the amount of events processed concurrently
+	Workers int
+	// QueueSize is the amount of events buffered before bees get blocked
+	QueueSize int
+	// Ordering is OrderingNone (the default), OrderingBee or OrderingKey
+	Ordering string
+	// Key gets rendered against an event's options to derive its ordering
+	// key, if Ordering is OrderingKey
+	Key string
+}
+
+// WorkQueueStats describes the state of a work queue. Without ordering, all
+// workers share a single queue, otherwise every worker has its own.
+type WorkQueueStats struct {
+	ID        string
+	Ordering  string
+	Workers   int
+	Active    int
+	Queued    int
+	Capacity  int
+	Processed uint64
+}
+
+type workQueue struct {
+	pool      *workerPool
+	jobs      chan func(*worker)
+	workers   int
+	active    int32
+	processed uint64
+}
+
+// worker is the handle a job gets from the worker running it. Jobs pass it
+// on to everything that might have to wait, see wait.
+type worker struct {
+	queue *workQueue
+	// set once the job handed its worker back to the queue
+	detached bool
+	// closed to free the worker a detached job borrowed, see resume
+	slot chan struct{}
+}
+
+type workerPool struct {
+	sync.RWMutex
+
+	config  WorkerPoolConfig
+	queues  []*workQueue
+	stopped bool
+}
+
+// Validate checks the config for invalid values.
+func (c *WorkerPoolConfig) Validate() error {
+	if c.Workers < 0 {
+		return errors.New("Workers must not be negative")
+	}
+	if c.QueueSize < 0 {
+		return errors.New("QueueSize must not be negative")
+	}
+
+	switch c.Ordering {
+	case "", OrderingNone, OrderingBee:
+	case OrderingKey:
+		if len(c.Key) == 0 {
+			return errors.New("Ordering by key requires a key template")
+		}
+	default:
+		return errors.New("Unknown ordering " + c.Ordering)
+	}
+
+	return nil
+}
+
+// SetWorkerPool configures how events get processed. Takes effect the next
+// time the bees get started.
+func SetWorkerPool(c WorkerPoolConfig) error {
+	return defaultEngine.SetWorkerPool(c)
+}
+
+// SetWorkerPool configures how the engine processes events.
+func (e *Engine) SetWorkerPool(c WorkerPoolConfig) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	e.workerMutex.Lock()
+	defer e.workerMutex.Unlock()
+	e.workerConfig = c
+
+	return nil
+}
+
+// GetWorkQueues returns the state of all work queues.
+func GetWorkQueues() []WorkQueueStats {
+	return defaultEngine.GetWorkQueues()
+}
+
+// GetWorkQueues returns the state of the engine's work queues.
+func (e *Engine) GetWorkQueues() []WorkQueueStats {
+	return e.pool().stats()
+}
+
+// GetWorkQueue returns the state of the work queue with a specific ID.
+func GetWorkQueue(id string) *WorkQueueStats {
+	return defaultEngine.GetWorkQueue(id)
+}
+
+// GetWorkQueue returns the state of the engine's work queue with a specific
+// ID.
+func (e *Engine) GetWorkQueue(id string) *WorkQueueStats {
+	for _, s := range e.GetWorkQueues() {
+		if s.ID == id {
+			return &s
+		}
+	}
+
+	return nil
+}
+
+// pool returns the engine's current worker pool.
+func (e *Engine) pool() *workerPool {
+	e.workerMutex.RLock()
+	defer e.workerMutex.RUnlock()
+
+	return e.workers
+}
+
+// startWorkers replaces the engine's worker pool with a fresh one. The old
+// pool finishes the events it already accepted.
+func (e *Engine) startWorkers() {
+	e.workerMutex.Lock()
+	defer e.workerMutex.Unlock()
+
+	if e.workers != nil {
+		e.workers.stop()
+	}
+	e.workers = newWorkerPool(e.workerConfig)
+}
+
+// stopWorkers stops the engine's worker pool once it processed all events
+// it already accepted.
+func (e *Engine) stopWorkers() {
+	e.pool().stop()
+}
+
+// orderingKey returns the key deciding which queue an event goes to.
+func (e *Engine) orderingKey(event *Event, c WorkerPoolConfig) string {
+	switch c.Ordering {
+	case OrderingBee:
+		return event.Bee
+	case OrderingKey:
+		m := make(map[string]interface{})
+		for _, opt := range event.Options {
+			m[opt.Name] = opt.Value
+		}
+		m["match"] = map[string]interface{}{
+			"bee":   event.Bee,
+			"event": event.Name,
+			"hive":  e.eventHive(event),
+		}
+
+		key, err := renderTemplate("orderingkey", c.Key, m)
+		if err != nil {
+			log.Errorln("Failed to render ordering key, ordering by bee:", err)
+			return event.Bee
+		}
+		return key
+	}
+
+	return ""
+}
+
+func newWorkerPool(c WorkerPoolConfig) *workerPool {
+	if c.Workers < 1 {
+		c.Workers = DefaultWorkers
+	}
+	if c.QueueSize < 1 {
+		c.QueueSize = DefaultWorkQueueSize
+	}
+	if len(c.Ordering) == 0 {
+		c.Ordering = OrderingNone
+	}
+
+	n := 1
+	if c.Ordering != OrderingNone {
+		n = c.Workers
+	}
+	size := c.QueueSize / n
+	if size < 1 {
+		size = 1
+	}
+
+	p := &workerPool{config: c}
+	for i := 0; i < n; i++ {
+		p.queues = append(p.queues, &workQueue{
+			pool:    p,
+			jobs:    make(chan func(*worker), size),
+			workers: c.Workers / n,
+		})
+	}
+	for i := 0; i < c.Workers; i++ {
+		go p.queues[i%n].work()
+	}
+
+	return p
+}
+
+// submit hands a job to the queue responsible for key. Blocks while the
+// queue is full, which in turn blocks the bees emitting events. Returns
+// false if the pool has been stopped.
+func (p *workerPool) submit(key string, job func(*worker)) bool {
+	q := p.queues[0]
+	if len(p.queues) > 1 {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		q = p.queues[h.Sum32()%uint32(len(p.queues))]
+	}
+
+	return q.submit(job)
+}
+
+// submit hands a job to the queue. Blocks while the queue is full. Returns
+// false if the pool has been stopped.
+func (q *workQueue) submit(job func(*worker)) bool {
+	q.pool.RLock()
+	defer q.pool.RUnlock()
+	if q.pool.stopped {
+		return false
+	}
+
+	q.jobs <- job
+	return true
+}
+
+// stop closes all queues. Workers exit once they processed the jobs still
+// queued.
+func (p *workerPool) stop() {
+	p.Lock()
+	defer p.Unlock()
+	if p.stopped {
+		return
+	}
+
+	p.stopped = true
+	for _, q := range p.queues {
+		close(q.jobs)
+	}
+}
+
+func (p *workerPool) stats() []WorkQueueStats {
+	r := []WorkQueueStats{}
+	for i, q := range p.queues {
+		r = append(r, WorkQueueStats{
+			ID:        strconv.Itoa(i),
+			Ordering:  p.config.Ordering,
+			Workers:   q.workers,
+			Active:    int(atomic.LoadInt32(&q.active)),
+			Queued:    len(q.jobs),
+			Capacity:  cap(q.jobs),
+			Processed: atomic.LoadUint64(&q.processed),
+		})
+	}
+
+	return r
+}
+
+// depth returns the amount of jobs waiting in all queues.
+func (p *workerPool) depth() int {
+	n := 0
+	for _, q := range p.queues {
+		n += len(q.jobs)
+	}
+
+	return n
+}
+
+func (q *workQueue) work() {
+	for job := range q.jobs {
+		w := &worker{queue: q}
+		q.run(w, job)
+
+		if w.detached {
+			// another worker took over this queue while the job waited
+			w.release()
+			return
+		}
+	}
+}
+
+func (q *workQueue) run(w *worker, job func(*worker)) {
+	atomic.AddInt32(&q.active, 1)
+	job(w)
+	if !w.detached {
+		atomic.AddInt32(&q.active, -1)
+	}
+	atomic.AddUint64(&q.processed, 1)
+}
+
+// wait pauses a job for d without occupying its worker. A new worker takes
+// over the queue while the job waits, so later jobs don't get stuck behind
+// it. Afterwards the job continues once a worker of its queue is free
+// again. Without a worker, wait simply sleeps.
+func (w *worker) wait(d time.Duration) {
+	if w == nil {
+		time.Sleep(d)
+		return
+	}
+
+	if !w.detached {
+		w.detached = true
+		atomic.AddInt32(&w.queue.active, -1)
+		go w.queue.work()
+	} else {
+		w.release()
+	}
+
+	time.Sleep(d)
+	w.resume()
+}
+
+// resume blocks a worker of the job's queue until the detached job is done
+// or waits again. Continues without a worker once the pool got stopped.
+func (w *worker) resume() {
+	slot := make(chan struct{})
+	resumed := make(chan struct{})
+	if !w.queue.submit(func(*worker) {
+		close(resumed)
+		<-slot
+	}) {
+		return
+	}
+
+	<-resumed
+	w.slot = slot
+}
+
+// release frees the worker a detached job borrowed.
+func (w *worker) release() {
+	if w.slot != nil {
+		close(w.slot)
+		w.slot = nil
+	}
+}
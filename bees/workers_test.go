@@ -0,0 +1,220 @@
+package bees
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolOrdering(t *testing.T) {
+	p := newWorkerPool(WorkerPoolConfig{Workers: 4, QueueSize: 64, Ordering: OrderingBee})
+	defer p.stop()
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string][]int)
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"a", "b", "c"} {
+			i, key := i, key
+			wg.Add(1)
+			p.submit(key, func(*worker) {
+				defer wg.Done()
+				mutex.Lock()
+				defer mutex.Unlock()
+				results[key] = append(results[key], i)
+			})
+		}
+	}
+	wg.Wait()
+
+	for key, r := range results {
+		for i, v := range r {
+			if v != i {
+				t.Fatalf("Events for key %s processed out of order: %v", key, r)
+			}
+		}
+	}
+}
+
+func TestWorkerPoolBackpressure(t *testing.T) {
+	p := newWorkerPool(WorkerPoolConfig{Workers: 1, QueueSize: 1})
+	defer p.stop()
+
+	block := make(chan bool)
+	started := make(chan bool)
+	p.submit("", func(*worker) {
+		started <- true
+		<-block
+	})
+	<-started
+	p.submit("", func(*worker) {})
+
+	if s := p.stats(); len(s) != 1 || s[0].Queued != 1 || s[0].Active != 1 {
+		t.Errorf("Unexpected queue state: %+v", s)
+	}
+
+	submitted := make(chan bool)
+	go func() {
+		p.submit("", func(*worker) {})
+		submitted <- true
+	}()
+
+	select {
+	case <-submitted:
+		t.Fatal("Expected submitting to a full queue to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(block)
+	select {
+	case <-submitted:
+	case <-time.After(time.Second):
+		t.Fatal("Expected submitting to continue once the queue drained")
+	}
+}
+
+func TestWorkerWait(t *testing.T) {
+	p := newWorkerPool(WorkerPoolConfig{Workers: 1, QueueSize: 4, Ordering: OrderingBee})
+	defer p.stop()
+
+	var mutex sync.Mutex
+	var order []string
+	record := func(s string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		order = append(order, s)
+	}
+
+	done := make(chan bool, 2)
+	p.submit("a", func(w *worker) {
+		record("retrying")
+		w.wait(50 * time.Millisecond)
+		record("retried")
+		done <- true
+	})
+	p.submit("a", func(*worker) {
+		record("next")
+		done <- true
+	})
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Expected a waiting job to free its worker")
+		}
+	}
+
+	mutex.Lock()
+	if len(order) != 3 || order[1] != "next" || order[2] != "retried" {
+		t.Errorf("Expected the next job to run while the first one waits, got %v", order)
+	}
+	mutex.Unlock()
+
+	// the queue must still have a single worker
+	block := make(chan bool)
+	started := make(chan bool, 2)
+	p.submit("a", func(*worker) {
+		started <- true
+		<-block
+	})
+	p.submit("a", func(*worker) {
+		started <- true
+	})
+	<-started
+	select {
+	case <-started:
+		t.Error("Expected the queue to be processed by a single worker")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(block)
+	<-started
+
+	for i := 0; i < 100 && p.stats()[0].Active != 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s := p.stats(); s[0].Active != 0 {
+		t.Errorf("Expected no active jobs, got %d", s[0].Active)
+	}
+}
+
+func TestEmitEventNeverBlocks(t *testing.T) {
+	e := NewEngine()
+	// pretend the event loop is running, but stuck
+	e.loopDone = make(chan struct{})
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 2000; i++ {
+			e.emitEvent(Event{Bee: "chain:stuck", Name: "output"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected emitting events to a stuck event loop not to block")
+	}
+	if n := len(e.takeEmitted()); n != 2000 {
+		t.Errorf("Expected all 2000 emitted events to wait for the loop, got %d", n)
+	}
+}
+
+func TestOrderingKey(t *testing.T) {
+	event := &Event{
+		Bee:     "keytest",
+		Name:    "message",
+		Options: Placeholders{{Name: "text", Type: "string", Value: "hello"}},
+	}
+
+	tests := []struct {
+		config   WorkerPoolConfig
+		expected string
+	}{
+		{WorkerPoolConfig{}, ""},
+		{WorkerPoolConfig{Ordering: OrderingBee}, "keytest"},
+		{WorkerPoolConfig{Ordering: OrderingKey, Key: "{{.match.event}}/{{.text}}"}, "message/hello"},
+		{WorkerPoolConfig{Ordering: OrderingKey, Key: "{{.text"}, "keytest"},
+	}
+
+	for _, test := range tests {
+		if key := defaultEngine.orderingKey(event, test.config); key != test.expected {
+			t.Errorf("Expected key %q for %+v, got %q", test.expected, test.config, key)
+		}
+	}
+}
+
+func TestWorkerPoolConfigValidate(t *testing.T) {
+	tests := []struct {
+		config WorkerPoolConfig
+		valid  bool
+	}{
+		{WorkerPoolConfig{}, true},
+		{WorkerPoolConfig{Workers: 8, QueueSize: 100, Ordering: OrderingBee}, true},
+		{WorkerPoolConfig{Ordering: OrderingKey, Key: "{{.text}}"}, true},
+		{WorkerPoolConfig{Ordering: OrderingKey}, false},
+		{WorkerPoolConfig{Ordering: "random"}, false},
+		{WorkerPoolConfig{Workers: -1}, false},
+	}
+
+	for _, test := range tests {
+		err := test.config.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("Unexpected validation result for %+v: %v", test.config, err)
+		}
+	}
+
+	e := NewEngine()
+	if err := e.SetWorkerPool(WorkerPoolConfig{Workers: 2, Ordering: OrderingBee}); err != nil {
+		t.Fatalf("Error configuring worker pool: %v", err)
+	}
+	e.startWorkers()
+	defer e.stopWorkers()
+
+	if q := e.GetWorkQueues(); len(q) != 2 {
+		t.Errorf("Expected a queue per worker, got %d", len(q))
+	}
+	if q := e.GetWorkQueue("1"); q == nil || q.Ordering != OrderingBee || q.Workers != 1 {
+		t.Errorf("Unexpected work queue: %+v", q)
+	}
+}
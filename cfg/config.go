@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/muesli/beehive/api/auth"
 	"github.com/muesli/beehive/bees"
 	gap "github.com/muesli/go-app-paths"
 	log "github.com/sirupsen/logrus"
@@ -20,6 +21,7 @@ type Config struct {
 	Bees    []bees.BeeConfig
 	Actions []bees.Action
 	Chains  []bees.Chain
+	Tokens  []auth.Token `json:",omitempty" yaml:",omitempty"`
 	backend ConfigBackend
 	url     *url.URL
 }
@@ -53,6 +55,7 @@ func (c *Config) Load() error {
 	c.Bees = config.Bees
 	c.Actions = config.Actions
 	c.Chains = config.Chains
+	c.Tokens = config.Tokens
 	return nil
 }
 
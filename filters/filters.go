@@ -32,6 +32,13 @@ type FilterInterface interface {
 	Passes(data map[string]interface{}, value string) bool
 }
 
+// EvaluatingFilter is implemented by filters which can report the value they
+// evaluated, e.g. the output of a rendered template.
+type EvaluatingFilter interface {
+	// Evaluate the filter, returning whether it passed and the evaluated value
+	Evaluate(data map[string]interface{}, value string) (bool, string, error)
+}
+
 var (
 	filters = make(map[string]*FilterInterface)
 )
@@ -46,6 +46,16 @@ func (filter *TemplateFilter) Description() string {
 
 // Passes returns true when the Filter matched the data.
 func (filter *TemplateFilter) Passes(data map[string]interface{}, v string) bool {
+	passed, _, err := filter.Evaluate(data, v)
+	if err != nil {
+		panic(err)
+	}
+
+	return passed
+}
+
+// Evaluate renders the template and returns whether the Filter matched the data.
+func (filter *TemplateFilter) Evaluate(data map[string]interface{}, v string) (bool, string, error) {
 	var res bytes.Buffer
 
 	if strings.Contains(v, "{{test") {
@@ -58,10 +68,10 @@ func (filter *TemplateFilter) Passes(data map[string]interface{}, v string) bool
 		err = tmpl.Execute(&res, data)
 	}
 	if err != nil {
-		panic(err)
+		return false, "", err
 	}
 
-	return strings.TrimSpace(res.String()) == "true"
+	return strings.TrimSpace(res.String()) == "true", res.String(), nil
 }
 
 func init() {
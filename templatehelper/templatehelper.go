@@ -47,6 +47,18 @@ var (
 		"Matches": func(s string, pattern string) (bool, error) {
 			return regexp.MatchString(pattern, s)
 		},
+		"Submatches": func(s string, pattern string) ([]string, error) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			return re.FindStringSubmatch(s), nil
+		},
+		"ParseJSON": func(s string) (interface{}, error) {
+			var v interface{}
+			err := json.Unmarshal([]byte(s), &v)
+			return v, err
+		},
 		"Mid": func(s string, left int, values ...int) string {
 			if left > len(s) {
 				left = len(s)
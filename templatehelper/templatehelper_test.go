@@ -59,6 +59,12 @@ func Test_FuncMap_Positive(t *testing.T) {
 
 		{`{{Last (Split "12,34,56" ",")}}`, "56"},
 
+		{`{{index (Submatches "nick: hello" "^(\\w+): (.*)$") 2}}`, "hello"},
+		{`{{len (Submatches "hello" "\\d+")}}`, "0"},
+
+		{`{{(ParseJSON "{\"host\": \"db\"}").host}}`, "db"},
+		{`{{index (ParseJSON "[1, 2]") 1}}`, "2"},
+
 		{`{{Mid "123456" 2}}`, "3456"},
 		{`{{Mid "123456" 10}}`, ""},
 		{`{{Mid "123456" 2 4}}`, "34"},
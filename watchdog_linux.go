@@ -1,7 +1,6 @@
 package main
 
 import (
-	"net/http"
 	"time"
 
 	"github.com/coreos/go-systemd/daemon"
@@ -35,9 +34,8 @@ func init() {
 		for {
 			select {
 			case <-time.After(runEvery):
-				resp, err := http.Get(api.CanonicalURL().String())
+				err := api.Ping()
 				if err == nil {
-					resp.Body.Close()
 					log.Debugf("Systemd watchdog notify")
 					daemon.SdNotify(false, daemon.SdNotifyWatchdog)
 				}